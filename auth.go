@@ -0,0 +1,232 @@
+package mockhttp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unauthorizedResponse returns a 401 Unauthorized response with
+// the WWW-Authenticate challenge.
+func unauthorizedResponse(r *http.Request, challenge, detail string) *http.Response {
+	resp := problemResponse(r, http.StatusUnauthorized, detail)
+	resp.Header.Set("WWW-Authenticate", challenge)
+	return resp
+}
+
+// secureEqual compares the strings in constant time
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// BasicAuth returns a Middleware that requires HTTP Basic
+// authentication (RFC 7617) of one of the users (username to
+// password). Requests without valid credentials get a 401
+// Unauthorized response with "WWW-Authenticate: Basic" challenge
+// of the realm.
+func BasicAuth(realm string, users map[string]string) Middleware {
+	copied := make(map[string]string, len(users))
+	for username, password := range users {
+		copied[username] = password
+	}
+	challenge := fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", realm)
+	return MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				return unauthorizedResponse(r, challenge, "missing credentials"), nil
+			}
+			if expected, found := copied[username]; !found || !secureEqual(expected, password) {
+				return unauthorizedResponse(r, challenge, "invalid username or password"), nil
+			}
+			return inner.RoundTrip(r)
+		})
+	})
+}
+
+// APIKeyAuth returns a Middleware that requires one of the keys in
+// the request header (e.g. "X-API-Key"). Requests without the header
+// get a 401 Unauthorized response. Requests with unknown key get a
+// 403 Forbidden response.
+func APIKeyAuth(header string, keys ...string) Middleware {
+	challenge := fmt.Sprintf("APIKey header=%q", header)
+	return MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			key := r.Header.Get(header)
+			if key == "" {
+				return unauthorizedResponse(r, challenge, "missing "+header), nil
+			}
+			for _, valid := range keys {
+				if secureEqual(valid, key) {
+					return inner.RoundTrip(r)
+				}
+			}
+			return problemResponse(r, http.StatusForbidden, "invalid "+header), nil
+		})
+	})
+}
+
+// bearerToken is a token issued by BearerAuth
+type bearerToken struct {
+	expiry time.Time // zero if never expires
+	scopes []string
+}
+
+// BearerAuth simulates OAuth 2.0 bearer token authentication
+// (RFC 6750) of a server. It issues tokens with expiry, and validates
+// the tokens of requests to the http.RoundTripper it wraps.
+type BearerAuth struct {
+	// Realm of the WWW-Authenticate challenges.
+	Realm string
+
+	// Now returns the current time to check the expiry of tokens.
+	// Default time.Now. It may be replaced to control the time in
+	// tests (see ManualClock).
+	Now func() time.Time
+
+	lock   sync.Mutex
+	tokens map[string]bearerToken
+	issued int
+}
+
+// NewBearerAuth returns a new BearerAuth of the realm. The zero value
+// of BearerAuth is also ready to use.
+func NewBearerAuth(realm string) *BearerAuth {
+	return &BearerAuth{
+		Realm:  realm,
+		Now:    time.Now,
+		tokens: make(map[string]bearerToken),
+	}
+}
+
+// now returns the current time of Now, or time.Now if not set
+func (ba *BearerAuth) now() time.Time {
+	if ba.Now == nil {
+		return time.Now()
+	}
+	return ba.Now()
+}
+
+// Issue a new token that expires after ttl, with the scopes. The
+// token never expires if ttl is 0.
+func (ba *BearerAuth) Issue(ttl time.Duration, scopes ...string) string {
+	token := newSessionID()
+	bt := bearerToken{scopes: scopes}
+	if ttl > 0 {
+		bt.expiry = ba.now().Add(ttl)
+	}
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+	if ba.tokens == nil {
+		ba.tokens = make(map[string]bearerToken)
+	}
+	ba.tokens[token] = bt
+	ba.issued++
+	return token
+}
+
+// Revoke the token.
+func (ba *BearerAuth) Revoke(token string) {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+	delete(ba.tokens, token)
+}
+
+// Issued returns the number of tokens issued.
+func (ba *BearerAuth) Issued() int {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+	return ba.issued
+}
+
+// TokenRT returns an http.RoundTripper that issues a new token, that
+// expires after ttl with the scopes, for every request. The token is
+// responded like an OAuth 2.0 token endpoint:
+//
+//	{"access_token": "...", "token_type": "Bearer", "expires_in": 3600}
+func (ba *BearerAuth) TokenRT(ttl time.Duration, scopes ...string) RoundTripperFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		token := struct {
+			AccessToken string `json:"access_token"`
+			TokenType   string `json:"token_type"`
+			ExpiresIn   int64  `json:"expires_in,omitempty"`
+			Scope       string `json:"scope,omitempty"`
+		}{
+			AccessToken: ba.Issue(ttl, scopes...),
+			TokenType:   "Bearer",
+			ExpiresIn:   int64(ttl / time.Second),
+			Scope:       strings.Join(scopes, " "),
+		}
+		content, _ := json.Marshal(token)
+		resp := bytesResponse(r, http.StatusOK, "application/json", content)
+		resp.Header.Set("Cache-Control", "no-store")
+		return resp, nil
+	}
+}
+
+// challenge returns the WWW-Authenticate challenge with the
+// parameters, which are pairs of name and value.
+func (ba *BearerAuth) challenge(params ...string) string {
+	challenge := fmt.Sprintf("Bearer realm=%q", ba.Realm)
+	for i := 0; i+1 < len(params); i += 2 {
+		challenge += fmt.Sprintf(", %s=%q", params[i], params[i+1])
+	}
+	return challenge
+}
+
+// Scope returns a Middleware that requires a valid token with all the
+// scopes. Requests without token, or with invalid or expired token,
+// get a 401 Unauthorized response. Requests with token without the
+// scopes get a 403 Forbidden response.
+func (ba *BearerAuth) Scope(scopes ...string) Middleware {
+	return MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			authorization := r.Header.Get("Authorization")
+			if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+				return unauthorizedResponse(r, ba.challenge(), "missing bearer token"), nil
+			}
+
+			ba.lock.Lock()
+			bt, found := ba.tokens[strings.TrimSpace(authorization[7:])]
+			ba.lock.Unlock()
+			if !found {
+				return unauthorizedResponse(r, ba.challenge(
+					"error", "invalid_token",
+					"error_description", "The access token is invalid",
+				), "invalid bearer token"), nil
+			}
+			if !bt.expiry.IsZero() && !ba.now().Before(bt.expiry) {
+				return unauthorizedResponse(r, ba.challenge(
+					"error", "invalid_token",
+					"error_description", "The access token expired",
+				), "expired bearer token"), nil
+			}
+
+		scopes:
+			for _, scope := range scopes {
+				for _, granted := range bt.scopes {
+					if granted == scope {
+						continue scopes
+					}
+				}
+				resp := problemResponse(r, http.StatusForbidden, "insufficient scope")
+				resp.Header.Set("WWW-Authenticate", ba.challenge(
+					"error", "insufficient_scope",
+					"scope", strings.Join(scopes, " "),
+				))
+				return resp, nil
+			}
+			return inner.RoundTrip(r)
+		})
+	})
+}
+
+// Wrap implements Middleware. Requests without a valid token get a
+// 401 Unauthorized response. Others will be passed to inner.
+func (ba *BearerAuth) Wrap(inner http.RoundTripper) http.RoundTripper {
+	return ba.Scope().Wrap(inner)
+}
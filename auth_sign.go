@@ -0,0 +1,265 @@
+package mockhttp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HMACConfig configures HMACAuth
+type HMACConfig struct {
+	// Header of the signature. Default "X-Signature".
+	Header string
+
+	// Prefix of the signature in the header (e.g. "sha256=", like
+	// the webhooks of GitHub). Default empty.
+	Prefix string
+
+	// Secret key of the HMAC. Required.
+	Secret []byte
+
+	// Hash of the HMAC. Default sha256.New.
+	Hash func() hash.Hash
+
+	// Message returns the signed message of the request with the
+	// body. Default the body.
+	Message func(r *http.Request, body []byte) []byte
+}
+
+// HMACAuth returns a Middleware that requires the request to be
+// signed with HMAC of the secret. The signature is hex encoded in
+// the header after the prefix, both case-insensitive. Requests
+// without signature get a 401 Unauthorized response. Requests with
+// invalid signature get a 403 Forbidden response.
+func HMACAuth(config HMACConfig) Middleware {
+	if config.Header == "" {
+		config.Header = "X-Signature"
+	}
+	if config.Hash == nil {
+		config.Hash = sha256.New
+	}
+	if config.Message == nil {
+		config.Message = func(r *http.Request, body []byte) []byte {
+			return body
+		}
+	}
+	challenge := fmt.Sprintf("HMAC header=%q", config.Header)
+	return MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			signature := r.Header.Get(config.Header)
+			if signature == "" {
+				return unauthorizedResponse(r, challenge, "missing "+config.Header), nil
+			}
+			body, err := readBody(r)
+			if err != nil {
+				return nil, fmt.Errorf("error reading request body: %s", err)
+			}
+			mac := hmac.New(config.Hash, config.Secret)
+			mac.Write(config.Message(r, body))
+			expected := hex.EncodeToString(mac.Sum(nil))
+			n := len(config.Prefix)
+			if len(signature) < n || !strings.EqualFold(signature[:n], config.Prefix) ||
+				!hmac.Equal([]byte(expected), []byte(strings.ToLower(signature[n:]))) {
+				return problemResponse(r, http.StatusForbidden, "signature mismatch"), nil
+			}
+			return inner.RoundTrip(r)
+		})
+	})
+}
+
+// AWSSigV4Config configures AWSSigV4Auth
+type AWSSigV4Config struct {
+	// Region of the credential scope (e.g. "us-east-1"). Required.
+	Region string
+
+	// Service of the credential scope (e.g. "s3"). Required.
+	Service string
+
+	// Credentials maps access key ids to secret access keys.
+	Credentials map[string]string
+
+	// MaxSkew is the maximum difference between the time of the
+	// request (X-Amz-Date) and now. Default 15 minutes.
+	MaxSkew time.Duration
+
+	// Now returns the current time. Default time.Now. It may be
+	// replaced to control the time in tests (see ManualClock).
+	Now func() time.Time
+}
+
+// awsSigV4Algorithm is the algorithm of AWS Signature Version 4
+const awsSigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// awsURIEncode encodes the string as in AWS Signature Version 4,
+// where only unreserved characters are not encoded.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// awsCanonicalRequest returns the canonical request of AWS Signature
+// Version 4 with the signed headers.
+func awsCanonicalRequest(r *http.Request, signedHeaders []string, payloadHash string) string {
+	uri := awsURIEncode(r.URL.Path, false)
+	if uri == "" {
+		uri = "/"
+	}
+
+	// sort parameters by encoded key, then by encoded value
+	query := r.URL.Query()
+	pairs := make([][2]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{awsURIEncode(key, true), awsURIEncode(value, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	params := make([]string, len(pairs))
+	for i, pair := range pairs {
+		params[i] = pair[0] + "=" + pair[1]
+	}
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		var values []string
+		if name == "host" {
+			values = []string{r.Host}
+			if r.Host == "" {
+				values = []string{r.URL.Host}
+			}
+		} else {
+			// copy to not modify the request header
+			values = append([]string(nil), r.Header.Values(name)...)
+		}
+		for i, value := range values {
+			values[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		uri,
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data with the key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// AWSSigV4Auth returns a Middleware that verifies the AWS Signature
+// Version 4 of requests in the Authorization header, e.g.:
+//
+//	Authorization: AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=host;x-amz-date, Signature=...
+//
+// The request should have X-Amz-Date header. The payload hash is taken
+// from the X-Amz-Content-Sha256 header, if any, or computed from the
+// body. The path is encoded once, like Amazon S3 does.
+//
+// Requests without the Authorization header get a 401 Unauthorized
+// response. Requests with unknown access key, wrong credential scope,
+// skewed time or invalid signature get a 403 Forbidden response.
+// Presigned URLs are not supported.
+func AWSSigV4Auth(config AWSSigV4Config) Middleware {
+	if config.MaxSkew == 0 {
+		config.MaxSkew = 15 * time.Minute
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			authorization := r.Header.Get("Authorization")
+			if !strings.HasPrefix(authorization, awsSigV4Algorithm+" ") {
+				return unauthorizedResponse(r, awsSigV4Algorithm, "missing signature"), nil
+			}
+
+			// parse Credential, SignedHeaders and Signature
+			fields := make(map[string]string)
+			for _, field := range strings.Split(authorization[len(awsSigV4Algorithm)+1:], ",") {
+				if i := strings.Index(field, "="); i != -1 {
+					fields[strings.TrimSpace(field[:i])] = strings.TrimSpace(field[i+1:])
+				}
+			}
+			credential := strings.Split(fields["Credential"], "/")
+			if len(credential) != 5 || fields["SignedHeaders"] == "" || fields["Signature"] == "" {
+				return problemResponse(r, http.StatusForbidden, "malformed authorization header"), nil
+			}
+			accessKey, date, region, service, terminal := credential[0], credential[1], credential[2], credential[3], credential[4]
+			secret, found := config.Credentials[accessKey]
+			if !found {
+				return problemResponse(r, http.StatusForbidden, fmt.Sprintf("unknown access key %s", accessKey)), nil
+			}
+			if region != config.Region || service != config.Service || terminal != "aws4_request" {
+				return problemResponse(r, http.StatusForbidden, fmt.Sprintf("invalid credential scope %s", fields["Credential"])), nil
+			}
+
+			// check time of request
+			amzDate := r.Header.Get("X-Amz-Date")
+			t, err := time.Parse("20060102T150405Z", amzDate)
+			if err != nil || !strings.HasPrefix(amzDate, date) {
+				return problemResponse(r, http.StatusForbidden, "invalid X-Amz-Date"), nil
+			}
+			if skew := config.Now().Sub(t); skew > config.MaxSkew || skew < -config.MaxSkew {
+				return problemResponse(r, http.StatusForbidden, "request time too skewed"), nil
+			}
+
+			// compute the signature
+			payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+			if payloadHash == "" {
+				body, err := readBody(r)
+				if err != nil {
+					return nil, fmt.Errorf("error reading request body: %s", err)
+				}
+				sum := sha256.Sum256(body)
+				payloadHash = hex.EncodeToString(sum[:])
+			}
+			signedHeaders := strings.Split(strings.ToLower(fields["SignedHeaders"]), ";")
+			canonicalRequest := sha256.Sum256([]byte(awsCanonicalRequest(r, signedHeaders, payloadHash)))
+			stringToSign := strings.Join([]string{
+				awsSigV4Algorithm,
+				amzDate,
+				strings.Join(credential[1:], "/"),
+				hex.EncodeToString(canonicalRequest[:]),
+			}, "\n")
+			key := hmacSHA256([]byte("AWS4"+secret), date)
+			key = hmacSHA256(key, region)
+			key = hmacSHA256(key, service)
+			key = hmacSHA256(key, "aws4_request")
+			signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+			if !hmac.Equal([]byte(signature), []byte(fields["Signature"])) {
+				return problemResponse(r, http.StatusForbidden, "signature mismatch"), nil
+			}
+			return inner.RoundTrip(r)
+		})
+	})
+}
@@ -0,0 +1,235 @@
+package mockhttp_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestHMACAuth(t *testing.T) {
+	secret := []byte("webhook secret")
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	var received string
+	inner := mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		content, _ := ioutil.ReadAll(r.Body)
+		received = string(content)
+		return mockhttp.StaticResponseRT("ok", "text/plain")(r)
+	})
+	client := mockhttp.NewClient(inner, mockhttp.ClientMiddleware(mockhttp.HMACAuth(mockhttp.HMACConfig{
+		Header: "X-Hub-Signature-256",
+		Prefix: "sha256=",
+		Secret: secret,
+	})))
+
+	tests := []struct {
+		body      string
+		signature string
+		status    int
+		challenge string
+		received  string
+	}{
+		{body: `{"action":"opened"}`, status: http.StatusUnauthorized, challenge: `HMAC header="X-Hub-Signature-256"`},
+		{body: `{"action":"opened"}`, signature: sign(`{"action":"closed"}`), status: http.StatusForbidden},
+		{body: `{"action":"opened"}`, signature: strings.TrimPrefix(sign(`{"action":"opened"}`), "sha256="), status: http.StatusForbidden},
+		{body: `{"action":"opened"}`, signature: sign(`{"action":"opened"}`), status: http.StatusOK, received: `{"action":"opened"}`},
+		{body: `{"action":"opened"}`, signature: strings.ToUpper(sign(`{"action":"opened"}`)), status: http.StatusOK, received: `{"action":"opened"}`},
+	}
+
+	for i, test := range tests {
+		received = ""
+		req, _ := http.NewRequest("POST", "https://hooks.example.com/", strings.NewReader(test.body))
+		if test.signature != "" {
+			req.Header.Set("X-Hub-Signature-256", test.signature)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.challenge, resp.Header.Get("WWW-Authenticate"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.received, received; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestAWSSigV4Auth(t *testing.T) {
+	// example of the AWS General Reference (Signature Version 4)
+	clock := mockhttp.NewManualClock(time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	client := mockhttp.NewClient(mockhttp.StaticResponseRT("ok", "text/plain"),
+		mockhttp.ClientMiddleware(mockhttp.AWSSigV4Auth(mockhttp.AWSSigV4Config{
+			Region:  "us-east-1",
+			Service: "iam",
+			Credentials: map[string]string{
+				"AKIDEXAMPLE": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			},
+			Now: clock.Now,
+		})))
+
+	const (
+		credential    = "Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request"
+		signedHeaders = "SignedHeaders=content-type;host;x-amz-date"
+		signature     = "Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	)
+
+	tests := []struct {
+		advance       time.Duration
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{status: http.StatusUnauthorized, challenge: "AWS4-HMAC-SHA256"},
+		{authorization: "AWS4-HMAC-SHA256 " + credential + ", " + signedHeaders + ", " + signature, status: http.StatusOK},
+		{authorization: "AWS4-HMAC-SHA256 " + credential + ", " + signedHeaders, status: http.StatusForbidden},
+		{authorization: "AWS4-HMAC-SHA256 Credential=AKIDUNKNOWN/20150830/us-east-1/iam/aws4_request, " + signedHeaders + ", " + signature, status: http.StatusForbidden},
+		{authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/eu-west-1/iam/aws4_request, " + signedHeaders + ", " + signature, status: http.StatusForbidden},
+		{authorization: "AWS4-HMAC-SHA256 " + credential + ", SignedHeaders=host;x-amz-date, " + signature, status: http.StatusForbidden},
+		{advance: 14 * time.Minute, authorization: "AWS4-HMAC-SHA256 " + credential + ", " + signedHeaders + ", " + signature, status: http.StatusOK},
+		{advance: 2 * time.Minute, authorization: "AWS4-HMAC-SHA256 " + credential + ", " + signedHeaders + ", " + signature, status: http.StatusForbidden},
+	}
+
+	for i, test := range tests {
+		clock.Advance(test.advance)
+		req, _ := http.NewRequest("GET", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		req.Header.Set("X-Amz-Date", "20150830T123600Z")
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.challenge, resp.Header.Get("WWW-Authenticate"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestAWSSigV4Auth_headerUntouched(t *testing.T) {
+	rt := mockhttp.AWSSigV4Auth(mockhttp.AWSSigV4Config{
+		Region:      "us-east-1",
+		Service:     "iam",
+		Credentials: map[string]string{"AKIDEXAMPLE": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		Now:         mockhttp.NewManualClock(time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)).Now,
+	}).Wrap(mockhttp.StaticResponseRT("ok", "text/plain"))
+
+	req, _ := http.NewRequest("GET", "https://iam.amazonaws.com/", nil)
+	req.Header.Set("X-Amz-Date", "20150830T123600Z")
+	req.Header.Set("X-Custom", "a   b")
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=host;x-amz-date;x-custom, Signature=0000")
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := "a   b", req.Header.Get("X-Custom"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestHMACAuth_prefix(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("hello"))
+	digest := hex.EncodeToString(mac.Sum(nil))
+
+	rt := mockhttp.HMACAuth(mockhttp.HMACConfig{
+		Prefix: "SHA256=",
+		Secret: []byte("secret"),
+	}).Wrap(mockhttp.StaticResponseRT("ok", "text/plain"))
+
+	tests := []struct {
+		signature string
+		status    int
+	}{
+		{signature: "SHA256=" + digest, status: http.StatusOK},
+		{signature: "sha256=" + strings.ToUpper(digest), status: http.StatusOK},
+		{signature: "SHA1=" + digest, status: http.StatusForbidden},
+		{signature: digest, status: http.StatusForbidden},
+		{signature: "SHA", status: http.StatusForbidden},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest("POST", "https://hooks.example.com/", strings.NewReader("hello"))
+		req.Header.Set("X-Signature", test.signature)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestAWSSigV4Auth_queryOrder(t *testing.T) {
+	hmacSHA256 := func(key []byte, data string) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(data))
+		return mac.Sum(nil)
+	}
+
+	// parameters sorted by key, then value, with "a" before "a-b"
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/",
+		"a=0&a=1&a-b=2",
+		"host:example.amazonaws.com",
+		"x-amz-date:20150830T123600Z",
+		"",
+		"host;x-amz-date",
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		"20150830T123600Z",
+		"20150830/us-east-1/service/aws4_request",
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"), "20150830")
+	key = hmacSHA256(key, "us-east-1")
+	key = hmacSHA256(key, "service")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	rt := mockhttp.AWSSigV4Auth(mockhttp.AWSSigV4Config{
+		Region:      "us-east-1",
+		Service:     "service",
+		Credentials: map[string]string{"AKIDEXAMPLE": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		Now:         mockhttp.NewManualClock(time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)).Now,
+	}).Wrap(mockhttp.StaticResponseRT("ok", "text/plain"))
+
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/?a-b=2&a=1&a=0", nil)
+	req.Header.Set("X-Amz-Date", "20150830T123600Z")
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature="+signature)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
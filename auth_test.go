@@ -0,0 +1,198 @@
+package mockhttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestBasicAuth(t *testing.T) {
+	client := mockhttp.NewClient(mockhttp.StaticResponseRT("hello world", "text/plain"),
+		mockhttp.ClientMiddleware(mockhttp.BasicAuth("example", map[string]string{"alice": "secret"})))
+
+	tests := []struct {
+		username  string
+		password  string
+		status    int
+		challenge string
+	}{
+		{status: http.StatusUnauthorized, challenge: `Basic realm="example", charset="UTF-8"`},
+		{username: "alice", password: "wrong", status: http.StatusUnauthorized, challenge: `Basic realm="example", charset="UTF-8"`},
+		{username: "bob", password: "secret", status: http.StatusUnauthorized, challenge: `Basic realm="example", charset="UTF-8"`},
+		{username: "alice", password: "secret", status: http.StatusOK},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+		if test.username != "" {
+			req.SetBasicAuth(test.username, test.password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.challenge, resp.Header.Get("WWW-Authenticate"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	client := mockhttp.NewClient(mockhttp.StaticResponseRT("hello world", "text/plain"),
+		mockhttp.ClientMiddleware(mockhttp.APIKeyAuth("X-API-Key", "key1", "key2")))
+
+	tests := []struct {
+		key       string
+		status    int
+		challenge string
+	}{
+		{status: http.StatusUnauthorized, challenge: `APIKey header="X-API-Key"`},
+		{key: "key3", status: http.StatusForbidden},
+		{key: "key1", status: http.StatusOK},
+		{key: "key2", status: http.StatusOK},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+		if test.key != "" {
+			req.Header.Set("X-API-Key", test.key)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.challenge, resp.Header.Get("WWW-Authenticate"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	clock := mockhttp.NewManualClock(time.Unix(1600000000, 0))
+	auth := mockhttp.NewBearerAuth("example")
+	auth.Now = clock.Now
+
+	read := auth.Issue(time.Minute, "read")
+	forever := auth.Issue(0, "read", "write")
+	revoked := auth.Issue(0, "read")
+	auth.Revoke(revoked)
+
+	inner := mockhttp.StaticResponseRT("hello world", "text/plain")
+	readClient := mockhttp.NewClient(inner, mockhttp.ClientMiddleware(auth))
+	writeClient := mockhttp.NewClient(inner, mockhttp.ClientMiddleware(auth.Scope("write")))
+
+	tests := []struct {
+		advance   time.Duration
+		client    *http.Client
+		token     string
+		status    int
+		challenge string
+	}{
+		{client: readClient, status: http.StatusUnauthorized, challenge: `Bearer realm="example"`},
+		{client: readClient, token: "unknown", status: http.StatusUnauthorized,
+			challenge: `Bearer realm="example", error="invalid_token", error_description="The access token is invalid"`},
+		{client: readClient, token: revoked, status: http.StatusUnauthorized,
+			challenge: `Bearer realm="example", error="invalid_token", error_description="The access token is invalid"`},
+		{client: readClient, token: read, status: http.StatusOK},
+		{client: writeClient, token: read, status: http.StatusForbidden,
+			challenge: `Bearer realm="example", error="insufficient_scope", scope="write"`},
+		{client: writeClient, token: forever, status: http.StatusOK},
+		{advance: time.Minute, client: readClient, token: read, status: http.StatusUnauthorized,
+			challenge: `Bearer realm="example", error="invalid_token", error_description="The access token expired"`},
+		{advance: time.Hour, client: writeClient, token: forever, status: http.StatusOK},
+	}
+
+	for i, test := range tests {
+		clock.Advance(test.advance)
+		req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		resp, err := test.client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.challenge, resp.Header.Get("WWW-Authenticate"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestBearerAuth_TokenRT(t *testing.T) {
+	auth := mockhttp.NewBearerAuth("example")
+	mux := mockhttp.NewRequestMux()
+	mux.Add(mockhttp.MatchPath("/token"), auth.TokenRT(time.Hour, "read"))
+	mux.Add(mockhttp.MatchPath("/data"), auth.Wrap(mockhttp.StaticResponseRT("hello world", "text/plain")))
+	client := mockhttp.NewClient(mux)
+
+	resp, err := client.Post("https://api.example.com/token", "application/x-www-form-urlencoded", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+	if want, have := "no-store", resp.Header.Get("Cache-Control"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := "Bearer", token.TokenType; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := int64(3600), token.ExpiresIn; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "read", token.Scope; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 1, auth.Issued(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	req, _ := http.NewRequest("GET", "https://api.example.com/data", nil)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestBearerAuth_zeroValue(t *testing.T) {
+	auth := &mockhttp.BearerAuth{Realm: "example"}
+	token := auth.Issue(time.Hour)
+
+	rt := auth.Wrap(mockhttp.StaticResponseRT("hello world", "text/plain"))
+	req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,95 @@
+package mockhttp
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FileCacheControl sets the Cache-Control header of the files served
+// by FileSystemRT. The default is "no-cache", which makes HTTP caches
+// revalidate the file with conditional requests. An empty value
+// omits the header.
+func FileCacheControl(value string) FileSystemOption {
+	return func(config *fileSystemConfig) {
+		config.cacheControl = value
+	}
+}
+
+// contentETag returns a strong ETag derived from the content, then
+// rewinds the content for reading.
+func contentETag(content io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x"`, h.Sum(nil)[:16]), nil
+}
+
+// etagMatch reports if the If-None-Match header value matches
+// the etag, with weak comparison.
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.TrimPrefix(item, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConditional evaluates If-None-Match and If-Modified-Since
+// headers of the request against the etag and modification time
+// of a file. It returns 304 (Not Modified) or 412 (Precondition
+// Failed) if the request should not be served with the file, or
+// 0 otherwise.
+//
+// As in RFC 7232, If-Modified-Since is ignored if If-None-Match
+// presents, and is only evaluated for GET and HEAD requests.
+func checkConditional(r *http.Request, etag string, modTime time.Time) int {
+	safe := r.Method == "" || r.Method == http.MethodGet || r.Method == http.MethodHead
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		if !etagMatch(header, etag) {
+			return 0
+		}
+		if safe {
+			return http.StatusNotModified
+		}
+		return http.StatusPreconditionFailed
+	}
+	if header := r.Header.Get("If-Modified-Since"); header != "" && safe {
+		t, err := http.ParseTime(header)
+		if err == nil && !modTime.Truncate(time.Second).After(t) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// notModifiedResponse returns a 304 (Not Modified) response with
+// the validators and cache headers of the given header.
+func notModifiedResponse(r *http.Request, header http.Header) *http.Response {
+	notModified := make(http.Header)
+	for _, key := range []string{"Cache-Control", "Date", "ETag", "Last-Modified", "Vary"} {
+		for _, value := range header.Values(key) {
+			notModified.Add(key, value)
+		}
+	}
+	return &http.Response{
+		Status:     http.StatusText(http.StatusNotModified),
+		StatusCode: http.StatusNotModified,
+		Proto:      r.Proto,
+		ProtoMajor: r.ProtoMajor,
+		ProtoMinor: r.ProtoMinor,
+		Request:    r,
+		Header:     notModified,
+		Body:       http.NoBody,
+	}
+}
@@ -0,0 +1,138 @@
+package mockhttp_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestFileSystemRT_cache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockhttp-cache")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	path := filepath.Join(dir, "hello.txt")
+	if err = ioutil.WriteFile(path, []byte("hello world"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	rt := mockhttp.FileSystemRT(dir)
+	req, _ := http.NewRequest("GET", "https://foobar.com/hello.txt", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Errorf("expected ETag header, got none")
+	}
+	if want, have := "Thu, 02 Jan 2020 03:04:05 GMT", resp.Header.Get("Last-Modified"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "no-cache", resp.Header.Get("Cache-Control"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	tests := []struct {
+		method string
+		header map[string]string
+		status int
+	}{
+		{method: "GET", header: map[string]string{"If-None-Match": etag}, status: http.StatusNotModified},
+		{method: "HEAD", header: map[string]string{"If-None-Match": etag}, status: http.StatusNotModified},
+		{method: "GET", header: map[string]string{"If-None-Match": `"other", W/` + etag}, status: http.StatusNotModified},
+		{method: "GET", header: map[string]string{"If-None-Match": "*"}, status: http.StatusNotModified},
+		{method: "GET", header: map[string]string{"If-None-Match": `"other"`}, status: http.StatusOK},
+		{method: "POST", header: map[string]string{"If-None-Match": etag}, status: http.StatusMethodNotAllowed},
+		{method: "GET", header: map[string]string{"If-Modified-Since": "Thu, 02 Jan 2020 03:04:05 GMT"}, status: http.StatusNotModified},
+		{method: "GET", header: map[string]string{"If-Modified-Since": "Fri, 03 Jan 2020 00:00:00 GMT"}, status: http.StatusNotModified},
+		{method: "GET", header: map[string]string{"If-Modified-Since": "Wed, 01 Jan 2020 00:00:00 GMT"}, status: http.StatusOK},
+		{method: "GET", header: map[string]string{"If-Modified-Since": "invalid"}, status: http.StatusOK},
+		{method: "POST", header: map[string]string{"If-Modified-Since": "Fri, 03 Jan 2020 00:00:00 GMT"}, status: http.StatusMethodNotAllowed},
+		{
+			method: "GET",
+			header: map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": "Fri, 03 Jan 2020 00:00:00 GMT",
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, "https://foobar.com/hello.txt", nil)
+		for key, value := range test.header {
+			req.Header.Set(key, value)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if test.status != http.StatusNotModified {
+			continue
+		}
+		if want, have := 0, len(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := etag, resp.Header.Get("ETag"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "", resp.Header.Get("Content-Type"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+
+	// ETag changes with the content
+	if err = ioutil.WriteFile(path, []byte("hello again"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req, _ = http.NewRequest("GET", "https://foobar.com/hello.txt", nil)
+	req.Header.Set("If-None-Match", etag)
+	if resp, err = rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Errorf("expected ETag to change with content")
+	}
+}
+
+func TestFileCacheControl(t *testing.T) {
+	tests := []struct {
+		option mockhttp.FileSystemOption
+		value  string
+	}{
+		{option: mockhttp.FileCacheControl("public, max-age=3600"), value: "public, max-age=3600"},
+		{option: mockhttp.FileCacheControl(""), value: ""},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+		resp, err := mockhttp.FileSystemRT("./testdata", test.option).RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.value, resp.Header.Get("Cache-Control"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package mockhttp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+)
+
+// certAuthority is a certificate authority that issues certificates
+// on the fly for mock servers.
+type certAuthority struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// newSerialNumber returns a random certificate serial number
+func newSerialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		panic("mockhttp: failed to generate serial number: " + err.Error())
+	}
+	return serial
+}
+
+// newKey generates a new private key for certificate
+func newKey() crypto.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic("mockhttp: failed to generate key: " + err.Error())
+	}
+	return key
+}
+
+// newCertAuthority generates a new self-signed certificate authority
+// of the given common name.
+func newCertAuthority(commonName string) *certAuthority {
+	key := newKey()
+	template := &x509.Certificate{
+		SerialNumber:          newSerialNumber(),
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"mockhttp"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		panic("mockhttp: failed to create CA certificate: " + err.Error())
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &certAuthority{cert: cert, key: key}
+}
+
+// issue a new leaf certificate for the host, valid between
+// notBefore and notAfter.
+func (ca *certAuthority) issue(host string, notBefore, notAfter time.Time) *tls.Certificate {
+	key := newKey()
+	template := &x509.Certificate{
+		SerialNumber: newSerialNumber(),
+		Subject:      pkix.Name{CommonName: host, Organization: []string{"mockhttp"}},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		panic("mockhttp: failed to create certificate: " + err.Error())
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return &tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+// certPool returns a new x509.CertPool with only the CA certificate
+func (ca *certAuthority) certPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// hostCerts issues and caches certificates of hosts with its own
+// certificate authority.
+type hostCerts struct {
+	ca    *certAuthority
+	lock  sync.Mutex
+	certs map[string]*tls.Certificate
+}
+
+func newHostCerts(commonName string) *hostCerts {
+	return &hostCerts{
+		ca:    newCertAuthority(commonName),
+		certs: make(map[string]*tls.Certificate),
+	}
+}
+
+// get the certificate of the host, issuing a new one if not
+// yet issued.
+func (hc *hostCerts) get(host string) *tls.Certificate {
+	hc.lock.Lock()
+	defer hc.lock.Unlock()
+	if cert, found := hc.certs[host]; found {
+		return cert
+	}
+	cert := hc.ca.issue(host, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+	hc.certs[host] = cert
+	return cert
+}
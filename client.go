@@ -0,0 +1,57 @@
+package mockhttp
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+)
+
+// ClientOption configures the http.Client created by NewClient.
+type ClientOption func(client *http.Client)
+
+// ClientTimeout sets the timeout of the http.Client.
+func ClientTimeout(timeout time.Duration) ClientOption {
+	return func(client *http.Client) {
+		client.Timeout = timeout
+	}
+}
+
+// ClientJar sets the cookie jar of the http.Client. If jar is nil,
+// a new empty cookiejar.Jar will be used.
+func ClientJar(jar http.CookieJar) ClientOption {
+	return func(client *http.Client) {
+		j := jar
+		if j == nil {
+			j, _ = cookiejar.New(nil) // never returns error with nil options
+		}
+		client.Jar = j
+	}
+}
+
+// ClientCheckRedirect sets the redirect policy of the http.Client.
+// See http.Client.CheckRedirect for details.
+func ClientCheckRedirect(fn func(req *http.Request, via []*http.Request) error) ClientOption {
+	return func(client *http.Client) {
+		client.CheckRedirect = fn
+	}
+}
+
+// ClientMiddleware wraps the transport of the http.Client with the
+// middlewares, from outer-most to inner-most.
+func ClientMiddleware(middlewares ...Middleware) ClientOption {
+	return func(client *http.Client) {
+		client.Transport = Chain(middlewares...).Wrap(client.Transport)
+	}
+}
+
+// NewClient returns a new http.Client with the given http.RoundTripper
+// as transport. The client is then configured by the options in order.
+func NewClient(rt http.RoundTripper, options ...ClientOption) *http.Client {
+	client := &http.Client{
+		Transport: rt,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
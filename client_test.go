@@ -0,0 +1,105 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestNewClient(t *testing.T) {
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	client := mockhttp.NewClient(
+		mockhttp.RedirectRT(http.StatusFound, "/new"),
+		mockhttp.ClientTimeout(5*time.Second),
+		mockhttp.ClientJar(nil),
+		mockhttp.ClientCheckRedirect(checkRedirect),
+		mockhttp.ClientMiddleware(
+			mockhttp.UseResponseModifier(mockhttp.ResponseSetHeader("X-Foo", "bar")),
+		),
+	)
+
+	if want, have := 5*time.Second, client.Timeout; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if client.Jar == nil {
+		t.Errorf("expected cookie jar, got nil")
+	}
+
+	resp, err := client.Get("https://foobar.com/old")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if want, have := http.StatusFound, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "bar", resp.Header.Get("X-Foo"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestNewClient_jar(t *testing.T) {
+	rt := mockhttp.UseResponseModifier(mockhttp.ResponseSetCookie(&http.Cookie{Name: "session", Value: "abc"})).
+		Wrap(mockhttp.StaticResponseRT("hello world", "text/plain"))
+
+	// the same option should give every client its own jar
+	option := mockhttp.ClientJar(nil)
+	client1 := mockhttp.NewClient(rt, option)
+	client2 := mockhttp.NewClient(rt, option)
+	if client1.Jar == client2.Jar {
+		t.Fatalf("expected separate cookie jars, got the same")
+	}
+
+	if _, err := client1.Get("https://foobar.com/"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	u, _ := url.Parse("https://foobar.com/")
+	if want, have := 1, len(client1.Jar.Cookies(u)); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 0, len(client2.Jar.Cookies(u)); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestNewClient_timeout(t *testing.T) {
+	slow := mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		select {
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		case <-time.After(time.Second):
+			return mockhttp.StaticResponseRT("hello world", "text/plain")(r)
+		}
+	})
+	client := mockhttp.NewClient(slow, mockhttp.ClientTimeout(10*time.Millisecond))
+	if _, err := client.Get("https://foobar.com/"); err == nil {
+		t.Errorf("expected error, got nil")
+	} else if uerr, ok := err.(*url.Error); !ok || !uerr.Timeout() {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func ExampleNewClient() {
+	mock := mockhttp.NewMuxRoundTripper()
+	mock.Add("api.service1.com", mockhttp.StaticResponseRT(`{"status": "OK"}`, "application/json"))
+
+	client := mock.NewClient(
+		mockhttp.ClientTimeout(5*time.Second),
+		mockhttp.ClientMiddleware(
+			mockhttp.UseResponseModifier(mockhttp.ResponseSetStatus(http.StatusAccepted)),
+		),
+	)
+
+	resp, _ := client.Get("https://api.service1.com/some/endpoint")
+	content, _ := ioutil.ReadAll(resp.Body)
+	fmt.Printf("%d %s\n", resp.StatusCode, content)
+
+	// Output: 202 {"status": "OK"}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/yookoala/mockhttp"
+)
+
+// Config is the declarative mock definition file
+type Config struct {
+	Routes []Route `json:"routes"`
+}
+
+// Route defines the mock response of requests matching the host,
+// method and path. Empty host, method or path matches anything.
+// Host "*" is the fallback of all other hosts. Path may contain
+// parameters like "/users/{id}" (see mockhttp.MatchPathPattern).
+// Routes of the same host are matched in order.
+//
+// Exactly one of Dir, BodyFile, Body or Template decides the response
+// body, in that order. Dir serves a fixture directory with
+// mockhttp.FileSystemRT. Template renders the body with
+// mockhttp.TemplateRT, with Path used as its path pattern. Dir
+// ignores Path.
+type Route struct {
+	Host        string            `json:"host"`
+	Method      string            `json:"method"`
+	Path        string            `json:"path"`
+	Status      int               `json:"status"`
+	ContentType string            `json:"contentType"`
+	Headers     map[string]string `json:"headers"`
+	Body        string            `json:"body"`
+	BodyFile    string            `json:"bodyFile"`
+	Template    string            `json:"template"`
+	Dir         string            `json:"dir"`
+}
+
+// loadConfig reads the config file
+func loadConfig(filename string) (*Config, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err = json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", filename, err)
+	}
+
+	// resolve file paths relative to the config file
+	base := filepath.Dir(filename)
+	for i := range config.Routes {
+		route := &config.Routes[i]
+		if route.Dir != "" && !filepath.IsAbs(route.Dir) {
+			route.Dir = filepath.Join(base, route.Dir)
+		}
+		if route.BodyFile != "" && !filepath.IsAbs(route.BodyFile) {
+			route.BodyFile = filepath.Join(base, route.BodyFile)
+		}
+	}
+	return config, nil
+}
+
+// roundTripper builds the http.RoundTripper of the route
+func (route Route) roundTripper() (http.RoundTripper, error) {
+	if route.Dir != "" {
+		if _, err := os.Stat(route.Dir); err != nil {
+			return nil, err
+		}
+		return mockhttp.FileSystemRT(route.Dir), nil
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	if route.Template != "" {
+		return mockhttp.TemplateRT(mockhttp.TemplateResponse{
+			Status:      status,
+			ContentType: contentType,
+			Header:      route.Headers,
+			Body:        route.Template,
+			PathPattern: route.Path,
+		}), nil
+	}
+
+	body := route.Body
+	if route.BodyFile != "" {
+		content, err := ioutil.ReadFile(route.BodyFile)
+		if err != nil {
+			return nil, err
+		}
+		body = string(content)
+	}
+	modifiers := []mockhttp.ResponseModifier{mockhttp.ResponseSetStatus(status)}
+	for key, value := range route.Headers {
+		modifiers = append(modifiers, mockhttp.ResponseSetHeader(key, value))
+	}
+	return mockhttp.UseResponseModifier(modifiers...).
+		Wrap(mockhttp.StaticResponseRT(body, contentType)), nil
+}
+
+// matcher builds the mockhttp.RequestMatcher of the route
+func (route Route) matcher() mockhttp.RequestMatcher {
+	matchers := []mockhttp.RequestMatcher{}
+	if route.Method != "" {
+		matchers = append(matchers, mockhttp.MatchMethod(route.Method))
+	}
+	if route.Path != "" && route.Dir == "" {
+		matchers = append(matchers, mockhttp.MatchPathPattern(route.Path))
+	}
+	return mockhttp.MatchAll(matchers...)
+}
+
+// roundTripper builds the http.RoundTripper of all the routes
+func (config *Config) roundTripper() (http.RoundTripper, error) {
+	hosts := make(map[string]*mockhttp.RequestMux)
+	mux := mockhttp.NewMuxRoundTripper()
+	for i, route := range config.Routes {
+		rt, err := route.roundTripper()
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %s", i, err)
+		}
+		host := route.Host
+		if host == "" {
+			host = "*"
+		}
+		if _, found := hosts[host]; !found {
+			hosts[host] = mockhttp.NewRequestMux()
+			hosts[host].Fallback(mockhttp.ServerErrorRT(http.StatusNotFound))
+			mux.Add(host, hosts[host])
+		}
+		hosts[host].Add(route.matcher(), rt)
+	}
+	return mux, nil
+}
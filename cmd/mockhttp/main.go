@@ -0,0 +1,154 @@
+// Command mockhttp serves mock HTTP responses on a local port, with
+// the same fixture directories and RoundTrippers used by Go tests of
+// the mockhttp library.
+//
+// Usage:
+//
+//	mockhttp [flags]
+//
+// Serve a fixture directory (see mockhttp.FileSystemRT):
+//
+//	mockhttp -addr :8080 -dir ./testdata
+//
+// Serve a declarative mock file:
+//
+//	mockhttp -addr :8080 -config mocks.json
+//
+// where mocks.json looks like:
+//
+//	{
+//	  "routes": [
+//	    {"host": "api.example.com", "method": "GET", "path": "/users/{id}",
+//	     "contentType": "application/json", "template": "{\"id\": {{.Params.id}}}"},
+//	    {"host": "api.example.com", "method": "DELETE", "status": 403},
+//	    {"host": "*", "dir": "./testdata"}
+//	  ]
+//	}
+//
+// Record responses of an upstream server into the fixture directory,
+// to be served later with -dir:
+//
+//	mockhttp -addr :8080 -dir ./testdata -record https://api.example.com
+//
+// Flags:
+//
+//	-addr        address to listen on (default "localhost:8080")
+//	-dir         fixture directory to serve, or to record into
+//	-config      declarative mock definition file (JSON)
+//	-latency     latency added to every response (e.g. 200ms)
+//	-fault-rate  probability, from 0 to 1, of responding 500 instead
+//	-record      upstream URL to proxy and record responses from
+//	-log         log every request to stderr
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	dir := flag.String("dir", "", "fixture directory to serve, or to record into")
+	configFile := flag.String("config", "", "declarative mock definition file (JSON)")
+	latency := flag.Duration("latency", 0, "latency added to every response (e.g. 200ms)")
+	faultRate := flag.Float64("fault-rate", 0, "probability, from 0 to 1, of responding 500 instead")
+	record := flag.String("record", "", "upstream URL to proxy and record responses from")
+	logRequests := flag.Bool("log", false, "log every request to stderr")
+	flag.Parse()
+
+	rt, err := newRoundTripper(*dir, *configFile, *record)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mockhttp: %s\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	middlewares := []mockhttp.Middleware{}
+	if *logRequests {
+		middlewares = append(middlewares, logMiddleware(log.New(os.Stderr, "", log.LstdFlags)))
+	}
+	if *latency > 0 {
+		middlewares = append(middlewares, latencyMiddleware(*latency))
+	}
+	if *faultRate > 0 {
+		middlewares = append(middlewares, faultMiddleware(*faultRate, rand.Float64))
+	}
+	rt = mockhttp.Chain(middlewares...).Wrap(rt)
+
+	log.Printf("mockhttp listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mockhttp.RoundTripperHandler(rt)))
+}
+
+// newRoundTripper builds the http.RoundTripper from the flags
+func newRoundTripper(dir, configFile, record string) (http.RoundTripper, error) {
+	switch {
+	case record != "":
+		if dir == "" {
+			return nil, fmt.Errorf("-record requires -dir to record into")
+		}
+		return recordRT(record, dir, http.DefaultTransport)
+	case configFile != "":
+		config, err := loadConfig(configFile)
+		if err != nil {
+			return nil, err
+		}
+		return config.roundTripper()
+	case dir != "":
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		return mockhttp.FileSystemRT(dir), nil
+	}
+	return nil, fmt.Errorf("one of -dir, -config or -record is required")
+}
+
+// logMiddleware logs every request with the response status
+// and time spent.
+func logMiddleware(logger *log.Logger) mockhttp.Middleware {
+	return mockhttp.MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := inner.RoundTrip(r)
+			if err != nil {
+				logger.Printf("%s %s error: %s (%s)", r.Method, r.URL, err, time.Since(start))
+			} else {
+				logger.Printf("%s %s %d (%s)", r.Method, r.URL, resp.StatusCode, time.Since(start))
+			}
+			return resp, err
+		})
+	})
+}
+
+// latencyMiddleware delays every response by the duration
+func latencyMiddleware(latency time.Duration) mockhttp.Middleware {
+	return mockhttp.MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return nil, r.Context().Err()
+			}
+			return inner.RoundTrip(r)
+		})
+	})
+}
+
+// faultMiddleware responds 500 Internal Server Error, instead of
+// passing to inner, when random() returns less than rate.
+func faultMiddleware(rate float64, random func() float64) mockhttp.Middleware {
+	return mockhttp.MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if random() < rate {
+				return mockhttp.ServerErrorRT(http.StatusInternalServerError)(r)
+			}
+			return inner.RoundTrip(r)
+		})
+	})
+}
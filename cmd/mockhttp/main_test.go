@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockhttp")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testdata, _ := filepath.Abs("../../testdata")
+	ioutil.WriteFile(filepath.Join(dir, "body.txt"), []byte("body from file"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "mocks.json"), []byte(`{
+  "routes": [
+    {"host": "api.example.com", "method": "GET", "path": "/users/{id}",
+     "contentType": "application/json", "template": "{\"id\": {{.Params.id}}}"},
+    {"host": "api.example.com", "method": "DELETE", "status": 403, "body": "no way",
+     "headers": {"X-Reason": "readonly"}},
+    {"host": "api.example.com", "path": "/file", "bodyFile": "body.txt"},
+    {"host": "*", "dir": `+strconv.Quote(testdata)+`}
+  ]
+}`), 0644)
+
+	// bodyFile is relative to the config file
+	config, err := loadConfig(filepath.Join(dir, "mocks.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	rt, err := config.roundTripper()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		method  string
+		url     string
+		status  int
+		content string
+		header  string
+	}{
+		{method: "GET", url: "http://api.example.com/users/12", status: 200, content: `{"id": 12}`},
+		{method: "DELETE", url: "http://api.example.com/users/12", status: 403, content: "no way", header: "readonly"},
+		{method: "GET", url: "http://api.example.com/file", status: 200, content: "body from file"},
+		{method: "GET", url: "http://api.example.com/other", status: 404, content: "Not Found"},
+		{method: "GET", url: "http://cdn.example.com/test.txt", status: 200, content: "hello world"},
+	}
+	client := mockhttp.NewClient(rt)
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.header, resp.Header.Get("X-Reason"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestRecordRT(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockhttp")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	upstream := mockhttp.MuxRoundTripper{}
+	upstream.Add("upstream.example.com", mockhttp.FileSystemRT("../../testdata"))
+	rt, err := recordRT("https://upstream.example.com", dir, upstream)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := mockhttp.NewClient(rt)
+	for _, path := range []string{"/persons/1.json", "/persons/2.json"} {
+		resp, err := client.Get("http://localhost:8080" + path)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			continue
+		}
+		ioutil.ReadAll(resp.Body)
+	}
+
+	// recorded file should be served the same
+	expected, _ := ioutil.ReadFile("../../testdata/persons/1.json")
+	if content, err := ioutil.ReadFile(filepath.Join(dir, "persons", "1.json")); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if want, have := string(expected), string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// not found response is not recorded
+	if _, err := os.Stat(filepath.Join(dir, "persons", "2.json")); !os.IsNotExist(err) {
+		t.Errorf("expected not exist error, got %#v", err)
+	}
+}
+
+func TestFaultMiddleware(t *testing.T) {
+	values := []float64{0.1, 0.9}
+	random := func() (v float64) {
+		v, values = values[0], values[1:]
+		return
+	}
+	client := mockhttp.NewClient(mockhttp.StaticResponseRT("hello", "text/plain"),
+		mockhttp.ClientMiddleware(faultMiddleware(0.5, random)))
+	for i, status := range []int{500, 200} {
+		resp, err := client.Get("http://api.example.com/")
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+		} else if want, have := status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
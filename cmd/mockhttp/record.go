@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yookoala/mockhttp"
+)
+
+// recordRT returns an http.RoundTripper that passes requests to the
+// upstream, with transport, and records the body of successful GET
+// responses into dir in the layout served by mockhttp.FileSystemRT.
+// Requests to directory paths (i.e. ending with "/") are not recorded.
+func recordRT(upstream, dir string, transport http.RoundTripper) (http.RoundTripper, error) {
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream URL: %s", err)
+	}
+	if upstreamURL.Scheme == "" || upstreamURL.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL: %s", upstream)
+	}
+
+	return mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		outreq := r.Clone(r.Context())
+		outreq.URL.Scheme = upstreamURL.Scheme
+		outreq.URL.Host = upstreamURL.Host
+		outreq.URL.Path = path.Join("/", upstreamURL.Path, r.URL.Path)
+		outreq.Host = ""
+
+		resp, err := transport.RoundTrip(outreq)
+		if err != nil || r.Method != http.MethodGet || resp.StatusCode != http.StatusOK ||
+			strings.HasSuffix(r.URL.Path, "/") {
+			return resp, err
+		}
+
+		content, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(content))
+
+		filename := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+		if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return nil, fmt.Errorf("error recording %s: %s", r.URL.Path, err)
+		}
+		if err = ioutil.WriteFile(filename, content, 0644); err != nil {
+			return nil, fmt.Errorf("error recording %s: %s", r.URL.Path, err)
+		}
+		return resp, nil
+	}), nil
+}
@@ -0,0 +1,192 @@
+package mockhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder wraps w with a writer that writes content encoded with
+// certain Content-Encoding.
+type Encoder func(w io.Writer) (io.WriteCloser, error)
+
+var encoders = map[string]Encoder{
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+	"deflate": func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriter(w), nil // "deflate" in HTTP is zlib format
+	},
+}
+var encodersLock sync.RWMutex
+
+// RegisterEncoder registers the Encoder of the Content-Encoding
+// name, for Compress to use. "gzip" and "deflate" are registered
+// by default. Other encodings, like "br", may be registered with
+// third-party implementations.
+func RegisterEncoder(name string, encoder Encoder) {
+	encodersLock.Lock()
+	defer encodersLock.Unlock()
+	encoders[strings.ToLower(name)] = encoder
+}
+
+// getEncoder returns the registered Encoder of the name, if any
+func getEncoder(name string) (encoder Encoder, found bool) {
+	encodersLock.RLock()
+	defer encodersLock.RUnlock()
+	encoder, found = encoders[name]
+	return
+}
+
+// acceptEncoding parses the Accept-Encoding header of the request
+// into map of encodings to their quality values.
+func acceptEncoding(r *http.Request) map[string]float64 {
+	accepted := make(map[string]float64)
+	for _, header := range r.Header["Accept-Encoding"] {
+		for _, item := range strings.Split(header, ",") {
+			params := strings.Split(item, ";")
+			name := strings.ToLower(strings.TrimSpace(params[0]))
+			if name == "" {
+				continue
+			}
+			q := 1.0
+			for _, param := range params[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						q = v
+					}
+				}
+			}
+			accepted[name] = q
+		}
+	}
+	return accepted
+}
+
+// acceptsEncoding reports if the request accepts the encoding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	accepted := acceptEncoding(r)
+	if q, found := accepted[encoding]; found {
+		return q > 0
+	}
+	q, found := accepted["*"]
+	return found && q > 0
+}
+
+// Compress returns a Middleware that encodes the response body with
+// the first of the encodings accepted by the request (according to
+// its Accept-Encoding header). The encodings should be registered
+// (see RegisterEncoder). If no encoding is given, "gzip" and
+// "deflate" will be used.
+//
+// Responses already with Content-Encoding, responses to HEAD requests
+// and responses without body (204 and 304) are not encoded.
+func Compress(encodings ...string) Middleware {
+	if len(encodings) == 0 {
+		encodings = []string{"gzip", "deflate"}
+	}
+	return MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			resp, err := inner.RoundTrip(r)
+			if err != nil || resp == nil || resp.Body == nil || r.Method == http.MethodHead ||
+				resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified ||
+				resp.Header.Get("Content-Encoding") != "" {
+				return resp, err
+			}
+			if resp.Header == nil {
+				resp.Header = make(http.Header)
+			}
+			resp.Header.Add("Vary", "Accept-Encoding")
+
+			for _, encoding := range encodings {
+				encoder, found := getEncoder(encoding)
+				if !found || !acceptsEncoding(r, encoding) {
+					continue
+				}
+
+				var buf bytes.Buffer
+				w, err := encoder(&buf)
+				if err != nil {
+					return nil, fmt.Errorf("error encoding response with %s: %s", encoding, err)
+				}
+				_, err = io.Copy(w, resp.Body)
+				resp.Body.Close()
+				if err == nil {
+					err = w.Close()
+				}
+				if err != nil {
+					return nil, fmt.Errorf("error encoding response with %s: %s", encoding, err)
+				}
+
+				resp.Body = ioutil.NopCloser(&buf)
+				resp.ContentLength = int64(buf.Len())
+				resp.Header.Set("Content-Length", strconv.Itoa(buf.Len()))
+				resp.Header.Set("Content-Encoding", encoding)
+				break
+			}
+			return resp, nil
+		})
+	})
+}
+
+// gunzipReader lazily creates gzip.Reader on first read, like
+// http.Transport does.
+type gunzipReader struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (gz *gunzipReader) Read(p []byte) (n int, err error) {
+	if gz.zr == nil && gz.err == nil {
+		gz.zr, gz.err = gzip.NewReader(gz.body)
+	}
+	if gz.err != nil {
+		return 0, gz.err
+	}
+	return gz.zr.Read(p)
+}
+
+func (gz *gunzipReader) Close() error {
+	return gz.body.Close()
+}
+
+// TransparentGunzip returns a Middleware that mimics the transparent
+// decompression of http.Transport: if the request has no
+// Accept-Encoding header, "Accept-Encoding: gzip" is added to the
+// request passed to inner. Then if the response is gzip encoded,
+// its body is decompressed, the Content-Encoding and Content-Length
+// headers are removed, ContentLength is set to -1 and Uncompressed
+// is set to true.
+func TransparentGunzip() Middleware {
+	return MiddlewareFunc(func(inner http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Header.Get("Accept-Encoding") != "" || r.Header.Get("Range") != "" ||
+				r.Method == http.MethodHead {
+				return inner.RoundTrip(r)
+			}
+
+			outreq := r.Clone(r.Context())
+			outreq.Header.Set("Accept-Encoding", "gzip")
+			resp, err := inner.RoundTrip(outreq)
+			if err != nil || resp == nil || resp.Body == nil ||
+				!strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+				return resp, err
+			}
+			resp.Body = &gunzipReader{body: resp.Body}
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			resp.Uncompressed = true
+			return resp, nil
+		})
+	})
+}
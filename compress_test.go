@@ -0,0 +1,181 @@
+package mockhttp_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func decode(encoding string, body io.Reader) (content []byte, err error) {
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(body)
+	case "deflate":
+		r, err = zlib.NewReader(body)
+	default:
+		r = body
+	}
+	if err != nil {
+		return
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestCompress(t *testing.T) {
+	rt := mockhttp.Compress().Wrap(mockhttp.StaticResponseRT("hello world", "text/plain"))
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{acceptEncoding: "", encoding: ""},
+		{acceptEncoding: "gzip", encoding: "gzip"},
+		{acceptEncoding: "deflate", encoding: "deflate"},
+		{acceptEncoding: "deflate, gzip;q=0.5", encoding: "gzip"},
+		{acceptEncoding: "gzip;q=0, deflate", encoding: "deflate"},
+		{acceptEncoding: "br", encoding: ""},
+		{acceptEncoding: "*", encoding: "gzip"},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://foobar.com/", nil)
+		if test.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", test.acceptEncoding)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.encoding, resp.Header.Get("Content-Encoding"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "Accept-Encoding", resp.Header.Get("Vary"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		raw, _ := ioutil.ReadAll(resp.Body)
+		if want, have := int64(len(raw)), resp.ContentLength; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if content, err := decode(test.encoding, bytes.NewReader(raw)); err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+		} else if want, have := "hello world", string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	mockhttp.RegisterEncoder("x-upper", func(w io.Writer) (io.WriteCloser, error) {
+		return &upperWriter{w}, nil
+	})
+	req, _ := http.NewRequest("GET", "https://foobar.com/", nil)
+	req.Header.Set("Accept-Encoding", "x-upper")
+	resp, err := mockhttp.Compress("x-upper").Wrap(mockhttp.StaticResponseRT("hello world", "text/plain")).RoundTrip(req)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := "HELLO WORLD", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+type upperWriter struct {
+	w io.Writer
+}
+
+func (uw *upperWriter) Write(p []byte) (int, error) {
+	return uw.w.Write([]byte(strings.ToUpper(string(p))))
+}
+
+func (uw *upperWriter) Close() error {
+	return nil
+}
+
+func TestTransparentGunzip(t *testing.T) {
+	client := mockhttp.NewClient(mockhttp.FileSystemRT("./testdata", mockhttp.FilePrecompressed()),
+		mockhttp.ClientMiddleware(mockhttp.TransparentGunzip()))
+
+	// transparent decompression
+	resp, err := client.Get("https://foobar.com/test.txt")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := "hello world", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if !resp.Uncompressed {
+		t.Errorf("expected Uncompressed to be true")
+	}
+	if want, have := int64(-1), resp.ContentLength; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "", resp.Header.Get("Content-Encoding"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// explicit Accept-Encoding is left to the caller
+	req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if resp.Uncompressed {
+		t.Errorf("expected Uncompressed to be false")
+	}
+	if want, have := "gzip", resp.Header.Get("Content-Encoding"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "text/plain; charset=utf-8", resp.Header.Get("Content-Type"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if content, err := decode("gzip", resp.Body); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if want, have := "hello world", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestFilePrecompressed(t *testing.T) {
+	rt := mockhttp.FileSystemRT("./testdata", mockhttp.FilePrecompressed())
+	tests := []struct {
+		path           string
+		acceptEncoding string
+		encoding       string
+	}{
+		{path: "/test.txt", acceptEncoding: "gzip, br", encoding: "gzip"},
+		{path: "/test.txt", acceptEncoding: "br", encoding: ""},
+		{path: "/test.txt", acceptEncoding: "", encoding: ""},
+		{path: "/test.json", acceptEncoding: "gzip", encoding: ""}, // no precompressed file
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://foobar.com"+test.path, nil)
+		req.Header.Set("Accept-Encoding", test.acceptEncoding)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.encoding, resp.Header.Get("Content-Encoding"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		raw, _ := ioutil.ReadAll(resp.Body)
+		if want, have := int64(len(raw)), resp.ContentLength; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
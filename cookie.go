@@ -0,0 +1,152 @@
+package mockhttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// ResponseSetCookie adds the cookies to the response, if presents,
+// as Set-Cookie headers.
+func ResponseSetCookie(cookies ...*http.Cookie) ResponseModifier {
+	return func(resp *http.Response, err error) (*http.Response, error) {
+		if resp != nil {
+			if resp.Header == nil {
+				resp.Header = make(http.Header)
+			}
+			for _, cookie := range cookies {
+				if v := cookie.String(); v != "" {
+					resp.Header.Add("Set-Cookie", v)
+				}
+			}
+		}
+		return resp, err
+	}
+}
+
+// MatchCookie matches requests with the cookie of the given name.
+// If value is not empty, the cookie should also have the value.
+func MatchCookie(name, value string) RequestMatcher {
+	return func(r *http.Request) bool {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return false
+		}
+		return value == "" || cookie.Value == value
+	}
+}
+
+// NewCookieClient returns a new http.Client with the given
+// http.RoundTripper as transport and an empty cookie jar.
+func NewCookieClient(rt http.RoundTripper) *http.Client {
+	return NewClient(rt, ClientJar(nil))
+}
+
+// SessionSimulator simulates cookie based session of a server.
+// It issues session cookies on login, and validates the session
+// cookie of requests to the http.RoundTripper it wraps.
+type SessionSimulator struct {
+	// CookieName is the name of the session cookie.
+	CookieName string
+
+	lock     sync.Mutex
+	sessions map[string]bool
+}
+
+// NewSessionSimulator returns a new SessionSimulator that issues
+// session cookie of the given name. The zero value of SessionSimulator
+// with CookieName set is also ready to use.
+func NewSessionSimulator(cookieName string) *SessionSimulator {
+	return &SessionSimulator{
+		CookieName: cookieName,
+		sessions:   make(map[string]bool),
+	}
+}
+
+// newSessionID generates a random session id.
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// session returns the valid session id of the request, if any.
+func (s *SessionSimulator) session(r *http.Request) (id string, ok bool) {
+	cookie, err := r.Cookie(s.CookieName)
+	if err != nil {
+		return "", false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return cookie.Value, s.sessions[cookie.Value]
+}
+
+// Login returns an http.RoundTripper that starts a new session and
+// sets the session cookie to the response of inner.
+//
+// The session cookie is only set if the response of inner is
+// successful (2xx or 3xx status code).
+func (s *SessionSimulator) Login(inner http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := inner.RoundTrip(r)
+		if err != nil || resp == nil || resp.StatusCode >= 400 {
+			return resp, err
+		}
+
+		id := newSessionID()
+		s.lock.Lock()
+		if s.sessions == nil {
+			s.sessions = make(map[string]bool)
+		}
+		s.sessions[id] = true
+		s.lock.Unlock()
+		return ResponseSetCookie(&http.Cookie{
+			Name:     s.CookieName,
+			Value:    id,
+			Path:     "/",
+			HttpOnly: true,
+		})(resp, err)
+	})
+}
+
+// Logout returns an http.RoundTripper that ends the session of the
+// request, if any, and expires the session cookie in the response
+// of inner.
+func (s *SessionSimulator) Logout(inner http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if id, ok := s.session(r); ok {
+			s.lock.Lock()
+			delete(s.sessions, id)
+			s.lock.Unlock()
+		}
+		return ResponseSetCookie(&http.Cookie{
+			Name:   s.CookieName,
+			Value:  "",
+			Path:   "/",
+			MaxAge: -1,
+		})(inner.RoundTrip(r))
+	})
+}
+
+// Wrap implements Middleware. Requests without a valid session
+// cookie will get a 401 Unauthorized response. Others will be
+// passed to inner.
+func (s *SessionSimulator) Wrap(inner http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if _, ok := s.session(r); !ok {
+			return ServerErrorRT(http.StatusUnauthorized)(r)
+		}
+		return inner.RoundTrip(r)
+	})
+}
+
+// Sessions returns the ids of all active sessions.
+func (s *SessionSimulator) Sessions() (ids []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	return
+}
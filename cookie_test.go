@@ -0,0 +1,126 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestResponseSetCookie(t *testing.T) {
+	client := mockhttp.NewCookieClient(mockhttp.UseResponseModifier(
+		mockhttp.ResponseSetCookie(
+			&http.Cookie{Name: "foo", Value: "bar", Path: "/"},
+			&http.Cookie{Name: "hello", Value: "world", Path: "/"},
+		),
+	).Wrap(mockhttp.StaticResponseRT("hello world", "text/plain")))
+
+	resp, err := client.Get("https://foobar.com/")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if want, have := []string{"foo=bar; Path=/", "hello=world; Path=/"}, resp.Header["Set-Cookie"]; fmt.Sprint(want) != fmt.Sprint(have) {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	u, _ := url.Parse("https://foobar.com/")
+	if want, have := 2, len(client.Jar.Cookies(u)); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestMatchCookie(t *testing.T) {
+	tests := []struct {
+		matcher mockhttp.RequestMatcher
+		match   bool
+	}{
+		{matcher: mockhttp.MatchCookie("foo", ""), match: true},
+		{matcher: mockhttp.MatchCookie("foo", "bar"), match: true},
+		{matcher: mockhttp.MatchCookie("foo", "baz"), match: false},
+		{matcher: mockhttp.MatchCookie("hello", ""), match: false},
+	}
+	for i, test := range tests {
+		r, _ := http.NewRequest("GET", "https://foobar.com/", nil)
+		r.AddCookie(&http.Cookie{Name: "foo", Value: "bar"})
+		if want, have := test.match, test.matcher(r); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestSessionSimulator(t *testing.T) {
+	session := mockhttp.NewSessionSimulator("SESSID")
+
+	mux := mockhttp.NewRequestMux()
+	mux.Add(
+		mockhttp.MatchAll(mockhttp.MatchPath("/login"), mockhttp.MatchFormValue("password", "secret")),
+		session.Login(mockhttp.StaticResponseRT("welcome", "text/plain")),
+	)
+	mux.Add(mockhttp.MatchPath("/login"), session.Login(mockhttp.ServerErrorRT(http.StatusForbidden)))
+	mux.Add(mockhttp.MatchPath("/logout"), session.Logout(mockhttp.StaticResponseRT("bye", "text/plain")))
+	mux.Fallback(session.Wrap(mockhttp.StaticResponseRT("private content", "text/plain")))
+
+	client := mockhttp.NewCookieClient(mux)
+	tests := []struct {
+		desc     string
+		path     string
+		form     url.Values
+		status   int
+		sessions int
+	}{
+		{desc: "before login", path: "/private", status: http.StatusUnauthorized, sessions: 0},
+		{desc: "failed login", path: "/login", form: url.Values{"password": {"wrong"}}, status: http.StatusForbidden, sessions: 0},
+		{desc: "still no access", path: "/private", status: http.StatusUnauthorized, sessions: 0},
+		{desc: "login", path: "/login", form: url.Values{"password": {"secret"}}, status: http.StatusOK, sessions: 1},
+		{desc: "access with session", path: "/private", status: http.StatusOK, sessions: 1},
+		{desc: "logout", path: "/logout", status: http.StatusOK, sessions: 0},
+		{desc: "no access after logout", path: "/private", status: http.StatusUnauthorized, sessions: 0},
+	}
+
+	for i, test := range tests {
+		var resp *http.Response
+		var err error
+		if test.form != nil {
+			resp, err = client.PostForm("https://foobar.com"+test.path, test.form)
+		} else {
+			resp, err = client.Get("https://foobar.com" + test.path)
+		}
+		if err != nil {
+			t.Errorf("[%d] %s: unexpected error: %s", i, test.desc, err)
+			continue
+		}
+		ioutil.ReadAll(resp.Body)
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] %s: expected %#v, got %#v", i, test.desc, want, have)
+		}
+		if want, have := test.sessions, len(session.Sessions()); want != have {
+			t.Errorf("[%d] %s: expected %#v, got %#v", i, test.desc, want, have)
+		}
+	}
+}
+
+func TestSessionSimulator_zeroValue(t *testing.T) {
+	session := &mockhttp.SessionSimulator{CookieName: "sid"}
+
+	mux := mockhttp.NewRequestMux()
+	mux.Add(mockhttp.MatchPath("/login"), session.Login(mockhttp.StaticResponseRT("welcome", "text/plain")))
+	mux.Fallback(session.Wrap(mockhttp.StaticResponseRT("private content", "text/plain")))
+	client := mockhttp.NewCookieClient(mux)
+
+	for i, path := range []string{"/login", "/private"} {
+		resp, err := client.Get("https://foobar.com" + path)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %s", i, err)
+		}
+		if want, have := http.StatusOK, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+	if want, have := 1, len(session.Sessions()); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,216 @@
+package mockhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirListing is the format of directory listing of FileSystemRT
+type DirListing string
+
+const (
+	// DirListingHTML lists directory entries in an HTML page, like
+	// http.FileServer does.
+	DirListingHTML DirListing = "html"
+
+	// DirListingJSON lists directory entries in a JSON array of
+	// objects with "name", "isDir", "size" and "modTime".
+	DirListingJSON DirListing = "json"
+
+	// DirListingAuto lists directory entries in JSON if the request
+	// accepts "application/json" (according to its Accept header),
+	// or in HTML otherwise.
+	DirListingAuto DirListing = "auto"
+)
+
+// FileDirListing makes FileSystemRT list the entries of directories,
+// in the format, instead of answering with 403 (Forbidden).
+//
+// Requests of directories without trailing slash are redirected to
+// the path with trailing slash, so relative links work.
+func FileDirListing(format DirListing) FileSystemOption {
+	switch format {
+	case DirListingHTML, DirListingJSON, DirListingAuto:
+	default:
+		panic(fmt.Sprintf("mockhttp: unsupported directory listing format %#v", format))
+	}
+	return func(config *fileSystemConfig) {
+		config.listing = format
+	}
+}
+
+// FileIndex makes FileSystemRT serve the first of the index files
+// found in a directory, for the request of the directory. If no name
+// is given, "index.html" and "index.json" will be used. Directories
+// without index file are listed if FileDirListing is given, or are
+// answered with 403 (Forbidden) otherwise.
+func FileIndex(names ...string) FileSystemOption {
+	if len(names) == 0 {
+		names = []string{"index.html", "index.json"}
+	}
+	return func(config *fileSystemConfig) {
+		config.indexes = names
+	}
+}
+
+// dirEntry is an entry of directory listing
+type dirEntry struct {
+	Name    string    `json:"name"`
+	IsDir   bool      `json:"isDir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// dirReader is a directory that can be listed
+type dirReader interface {
+	ReadDir(n int) ([]fs.DirEntry, error)
+}
+
+// memDir is an in-memory directory
+type memDir struct {
+	*memFile
+	entries []fs.DirEntry
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	return d.entries, nil
+}
+
+// serveDir serves the request of the directory with index file,
+// directory listing or 403 (Forbidden).
+func (config *fileSystemConfig) serveDir(r *http.Request, dir file) (*http.Response, error) {
+	defer dir.Close()
+	if len(config.indexes) == 0 && config.listing == "" {
+		return statusTextResponse(r, http.StatusForbidden), nil
+	}
+	dirPath := r.URL.Path
+	if dirPath == "" {
+		dirPath = "/"
+	}
+	if !strings.HasSuffix(dirPath, "/") {
+		location := path.Base(r.URL.Path) + "/"
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		return redirectResponse(r, http.StatusMovedPermanently, location), nil
+	}
+
+	// serve index file, if found
+	for _, name := range config.indexes {
+		index, _, err := config.open(dirPath + name)
+		if err != nil {
+			continue
+		}
+		s, err := index.Stat()
+		index.Close()
+		if err != nil || s.IsDir() {
+			continue
+		}
+		ir := r.Clone(r.Context())
+		ir.URL.Path, ir.URL.RawPath = dirPath+name, ""
+		resp, err := config.serveFile(ir)
+		if resp != nil {
+			resp.Request = r
+		}
+		return resp, err
+	}
+
+	if config.listing == "" {
+		return statusTextResponse(r, http.StatusForbidden), nil
+	}
+	entries, err := config.readDir(dirPath, dir)
+	if err != nil {
+		return statusTextResponse(r, http.StatusInternalServerError), nil
+	}
+
+	format := config.listing
+	if format == DirListingAuto {
+		format = DirListingHTML
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			format = DirListingJSON
+		}
+	}
+	var resp *http.Response
+	if format == DirListingJSON {
+		content, _ := json.Marshal(entries)
+		resp = bytesResponse(r, http.StatusOK, "application/json", content)
+	} else {
+		resp = bytesResponse(r, http.StatusOK, "text/html; charset=utf-8", dirListingHTML(entries))
+	}
+	if config.listing == DirListingAuto {
+		resp.Header.Set("Vary", "Accept")
+	}
+	return resp, nil
+}
+
+// readDir reads the entries of the directory, with the files written
+// to or deleted from the overlay, sorted by name.
+func (config *fileSystemConfig) readDir(name string, dir file) ([]dirEntry, error) {
+	reader, ok := dir.(dirReader)
+	if !ok {
+		return nil, fmt.Errorf("directory %s cannot be read", name)
+	}
+	des, err := reader.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]dirEntry, len(des))
+	for _, de := range des {
+		info, err := de.Info()
+		if err != nil {
+			return nil, err
+		}
+		found[de.Name()] = dirEntry{
+			Name:    de.Name(),
+			IsDir:   de.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+	}
+	if config.overlay != nil {
+		written, deleted := config.overlay.entries(name)
+		for name := range deleted {
+			delete(found, name)
+		}
+		for name, entry := range written {
+			found[name] = entry
+		}
+	}
+
+	entries := make([]dirEntry, 0, len(found))
+	for _, entry := range found {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// dirListingHTML renders the entries into HTML page
+func dirListingHTML(entries []dirEntry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<!doctype html>\n")
+	buf.WriteString("<meta name=\"viewport\" content=\"width=device-width\">\n")
+	buf.WriteString("<pre>\n")
+	for _, entry := range entries {
+		name := entry.Name
+		if entry.IsDir {
+			name += "/"
+		}
+		href := url.URL{Path: name}
+		fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>\n",
+			html.EscapeString(href.String()), html.EscapeString(name))
+	}
+	buf.WriteString("</pre>\n")
+	return buf.Bytes()
+}
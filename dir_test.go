@@ -0,0 +1,209 @@
+package mockhttp_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestFileDirListing(t *testing.T) {
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"users/1.json":        {Data: []byte(`{"id":1}`), ModTime: modTime},
+		"users/2.json":        {Data: []byte(`{"id":2}`), ModTime: modTime},
+		"users/admins/3.json": {Data: []byte(`{"id":3}`), ModTime: modTime},
+		"site/index.html":     {Data: []byte("<h1>home</h1>")},
+		"api/index.json":      {Data: []byte(`{"api":true}`)},
+		"a b/<script>.txt":    {Data: []byte("escaped")},
+		"docs/readme.txt":     {Data: []byte("")},
+	}
+
+	tests := []struct {
+		rt          http.RoundTripper
+		path        string
+		accept      string
+		status      int
+		location    string
+		contentType string
+		content     string
+	}{
+		{
+			rt:          mockhttp.FSRT(fsys),
+			path:        "/users/",
+			status:      http.StatusForbidden,
+			contentType: "text/plain",
+			content:     "Forbidden",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileDirListing(mockhttp.DirListingHTML)),
+			path:        "/users/",
+			status:      http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			content: "<!doctype html>\n" +
+				"<meta name=\"viewport\" content=\"width=device-width\">\n" +
+				"<pre>\n" +
+				"<a href=\"1.json\">1.json</a>\n" +
+				"<a href=\"2.json\">2.json</a>\n" +
+				"<a href=\"admins/\">admins/</a>\n" +
+				"</pre>\n",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileDirListing(mockhttp.DirListingHTML)),
+			path:        "/a%20b/",
+			status:      http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			content: "<!doctype html>\n" +
+				"<meta name=\"viewport\" content=\"width=device-width\">\n" +
+				"<pre>\n" +
+				"<a href=\"%3Cscript%3E.txt\">&lt;script&gt;.txt</a>\n" +
+				"</pre>\n",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileDirListing(mockhttp.DirListingJSON)),
+			path:        "/users/admins/",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			content:     `[{"name":"3.json","isDir":false,"size":8,"modTime":"2020-01-02T03:04:05Z"}]`,
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileDirListing(mockhttp.DirListingAuto)),
+			path:        "/users/admins/",
+			accept:      "application/json",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			content:     `[{"name":"3.json","isDir":false,"size":8,"modTime":"2020-01-02T03:04:05Z"}]`,
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileDirListing(mockhttp.DirListingAuto)),
+			path:        "/users/admins/",
+			accept:      "text/html",
+			status:      http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			content: "<!doctype html>\n" +
+				"<meta name=\"viewport\" content=\"width=device-width\">\n" +
+				"<pre>\n" +
+				"<a href=\"3.json\">3.json</a>\n" +
+				"</pre>\n",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileDirListing(mockhttp.DirListingJSON)),
+			path:        "/users?page=1",
+			status:      http.StatusMovedPermanently,
+			location:    "users/?page=1",
+			contentType: "text/html; charset=utf-8",
+			content:     "<a href=\"users/?page=1\">Moved Permanently</a>.\n",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileIndex()),
+			path:        "/site/",
+			status:      http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			content:     "<h1>home</h1>",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileIndex()),
+			path:        "/api/",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			content:     `{"api":true}`,
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileIndex()),
+			path:        "/users/",
+			status:      http.StatusForbidden,
+			contentType: "text/plain",
+			content:     "Forbidden",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileIndex(), mockhttp.FileDirListing(mockhttp.DirListingJSON)),
+			path:        "/docs/",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			content:     `[{"name":"readme.txt","isDir":false,"size":0,"modTime":"0001-01-01T00:00:00Z"}]`,
+		},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://foobar.com"+test.path, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		resp, err := test.rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.location, resp.Header.Get("Location"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.contentType, resp.Header.Get("Content-Type"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestFileDirListing_writable(t *testing.T) {
+	rt := mockhttp.FileSystemRT("./testdata",
+		mockhttp.FileWritable(),
+		mockhttp.FileDirListing(mockhttp.DirListingJSON))
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: "PUT", path: "/persons/2.json", body: `{"id":2}`},
+		{method: "PUT", path: "/persons/deep/3.json", body: `{"id":3}`},
+		{method: "DELETE", path: "/persons/1.json"},
+	}
+	for i, request := range requests {
+		req, _ := http.NewRequest(request.method, "https://foobar.com"+request.path, strings.NewReader(request.body))
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatalf("[%d] unexpected error: %s", i, err)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "https://foobar.com/persons/", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var entries []struct {
+		Name  string `json:"name"`
+		IsDir bool   `json:"isDir"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := 2, len(entries); want != have {
+		t.Fatalf("expected %#v, got %#v (%#v)", want, have, entries)
+	}
+	if want, have := "2.json", entries[0].Name; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "deep", entries[1].Name; want != have || !entries[1].IsDir {
+		t.Errorf("expected directory %#v, got %#v", want, entries[1])
+	}
+}
+
+func TestFileDirListing_unsupported(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic, got nil")
+		}
+	}()
+	mockhttp.FileDirListing("xml")
+}
@@ -66,7 +66,7 @@ MuxRoundTripper also can be used directly to create client.
 
 	mux := mockhttp.MuxRoundTripper{}
 	...
-	mux.GetClient().Post("http://foobar.com", strings.NewReader("some+data"))
+	mux.NewClient().Post("http://foobar.com", strings.NewReader("some+data"))
 
 Partial Override
 
@@ -81,8 +81,19 @@ You can partially override the round trip like this:
 	// "*" for setting fallback http.RoundTripper
 	mux.Add("*", http.DefaultTransport)
 
-	client := mux.GetClient()
+	client := mux.NewClient()
 	...
 
+Client Options
+
+NewClient creates an http.Client for any http.RoundTripper. Options
+may be given to build a client closer to the one used in production:
+
+	client := mockhttp.NewClient(mux,
+		mockhttp.ClientTimeout(5*time.Second),
+		mockhttp.ClientJar(nil), // with a new cookie jar
+		mockhttp.ClientMiddleware(someMiddleware),
+	)
+
 */
 package mockhttp
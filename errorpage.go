@@ -0,0 +1,69 @@
+package mockhttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+)
+
+// FileErrorPage makes FileSystemRT respond the file of name, instead
+// of the plain status text, for responses of the error status. The
+// file is looked up like any request path (e.g. "/errors/404.html").
+// The status text is still responded if the file cannot be opened.
+//
+// FileErrorPage panics if status is not an error status (4xx or 5xx).
+func FileErrorPage(status int, name string) FileSystemOption {
+	if status < 400 || status > 599 {
+		panic(fmt.Sprintf("mockhttp: %d is not an error status", status))
+	}
+	return func(config *fileSystemConfig) {
+		if config.errorPages == nil {
+			config.errorPages = make(map[int]string)
+		}
+		config.errorPages[status] = name
+	}
+}
+
+// openErrorStatus maps the error of opening a file into the status
+// of response.
+func openErrorStatus(err error) int {
+	switch {
+	case os.IsNotExist(err), errors.Is(err, syscall.ENOTDIR):
+		return http.StatusNotFound
+	case os.IsPermission(err):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// useErrorPage replaces the body of the response with the error page
+// of name, if it can be read. The content type of the response is kept
+// if the extension of name has no known type.
+func (config *fileSystemConfig) useErrorPage(resp *http.Response, name string) {
+	f, _, err := config.open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if s, err := f.Stat(); err != nil || s.IsDir() {
+		return
+	}
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	resp.Header.Set("Content-Length", strconv.Itoa(len(content)))
+	resp.ContentLength = int64(len(content))
+	resp.Body = ioutil.NopCloser(bytes.NewReader(content))
+}
@@ -0,0 +1,206 @@
+package mockhttp_test
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestFileSystemRT_confinement(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockhttp-confinement")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// ${dir}/secret.txt is outside of ${dir}/root
+	root := filepath.Join(dir, "root")
+	if err = os.Mkdir(root, 0755); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(root, "public.txt"), []byte("public"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "escape.txt")); err != nil {
+		t.Skipf("symlink not supported: %s", err)
+	}
+	if err = os.Symlink(dir, filepath.Join(root, "parent")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = os.Symlink("public.txt", filepath.Join(root, "link.txt")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		path    string
+		status  int
+		content string
+	}{
+		{path: "/public.txt", status: http.StatusOK, content: "public"},
+		{path: "/link.txt", status: http.StatusOK, content: "public"},
+		{path: "/../secret.txt", status: http.StatusNotFound, content: "Not Found"},
+		{path: "/%2e%2e/secret.txt", status: http.StatusNotFound, content: "Not Found"},
+		{path: "/escape.txt", status: http.StatusForbidden, content: "Forbidden"},
+		{path: "/parent/secret.txt", status: http.StatusForbidden, content: "Forbidden"},
+		{path: "/public.txt/nothing", status: http.StatusNotFound, content: "Not Found"},
+	}
+
+	rt := mockhttp.FileSystemRT(root)
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://foobar.com"+test.path, nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestFileSystemRT_permission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permission is not enforced for root")
+	}
+	dir, err := ioutil.TempDir("", "mockhttp-permission")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "private.txt"), []byte("private"), 0000); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://foobar.com/private.txt", nil)
+	resp, err := mockhttp.FileSystemRT(dir).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusForbidden, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+// brokenFS fails to open any file with an I/O error
+type brokenFS struct{}
+
+func (brokenFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("input/output error")}
+}
+
+func TestFileErrorPage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.txt":       {Data: []byte("hello world")},
+		"errors/404.html": {Data: []byte("<h1>Page Not Found</h1>")},
+		"errors/405.json": {Data: []byte(`{"error":"method not allowed"}`)},
+		"errors/405.page": {Data: []byte("Something went wrong")},
+	}
+
+	tests := []struct {
+		rt          http.RoundTripper
+		method      string
+		path        string
+		status      int
+		contentType string
+		content     string
+	}{
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileErrorPage(http.StatusNotFound, "/errors/404.html")),
+			method:      "GET",
+			path:        "/nothing.txt",
+			status:      http.StatusNotFound,
+			contentType: "text/html; charset=utf-8",
+			content:     "<h1>Page Not Found</h1>",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileErrorPage(http.StatusNotFound, "/errors/404.html")),
+			method:      "HEAD",
+			path:        "/nothing.txt",
+			status:      http.StatusNotFound,
+			contentType: "text/html; charset=utf-8",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileErrorPage(http.StatusMethodNotAllowed, "/errors/405.json")),
+			method:      "POST",
+			path:        "/hello.txt",
+			status:      http.StatusMethodNotAllowed,
+			contentType: "application/json",
+			content:     `{"error":"method not allowed"}`,
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileErrorPage(http.StatusNotFound, "/errors/missing.html")),
+			method:      "GET",
+			path:        "/nothing.txt",
+			status:      http.StatusNotFound,
+			contentType: "text/plain",
+			content:     "Not Found",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileErrorPage(http.StatusNotFound, "/errors/404.html")),
+			method:      "GET",
+			path:        "/hello.txt",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			content:     "hello world",
+		},
+		{
+			rt:          mockhttp.FSRT(fsys, mockhttp.FileErrorPage(http.StatusMethodNotAllowed, "/errors/405.page")),
+			method:      "POST",
+			path:        "/hello.txt",
+			status:      http.StatusMethodNotAllowed,
+			contentType: "text/plain",
+			content:     "Something went wrong",
+		},
+		{
+			rt:          mockhttp.FSRT(brokenFS{}),
+			method:      "GET",
+			path:        "/hello.txt",
+			status:      http.StatusInternalServerError,
+			contentType: "text/plain",
+			content:     "Internal Server Error",
+		},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, "https://foobar.com"+test.path, nil)
+		resp, err := test.rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.contentType, resp.Header.Get("Content-Type"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestFileErrorPage_invalidStatus(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic, got nil")
+		}
+	}()
+	mockhttp.FileErrorPage(http.StatusOK, "/ok.html")
+}
@@ -0,0 +1,70 @@
+package mockhttp
+
+import (
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// FSRT implements http.RoundTripper by returning contents of files
+// in the fs.FS, just like FileSystemRT. It allows fixtures to be
+// served from embed.FS, fstest.MapFS, zip.Reader or any other
+// implementation:
+//
+//	//go:embed testdata
+//	var fixtures embed.FS
+//
+//	...
+//
+//	testdata, _ := fs.Sub(fixtures, "testdata")
+//	client := mockhttp.NewClient(mockhttp.FSRT(testdata))
+//
+// Files that are not seekable are read into memory when opened.
+func FSRT(fsys fs.FS, options ...FileSystemOption) RoundTripperFunc {
+	return newFileSystemConfig(func(name string) (file, error) {
+		return openFS(fsys, name)
+	}, options).serve
+}
+
+// fsName converts the request path into the name for fs.FS
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// openFS opens the file of the request path in fsys
+func openFS(fsys fs.FS, name string) (file, error) {
+	f, err := fsys.Open(fsName(name))
+	if err != nil {
+		return nil, err
+	}
+	if seekable, ok := f.(file); ok {
+		return seekable, nil
+	}
+
+	// read the file into memory
+	defer f.Close()
+	s, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if s.IsDir() {
+		var entries []fs.DirEntry
+		if reader, ok := f.(fs.ReadDirFile); ok {
+			if entries, err = reader.ReadDir(-1); err != nil {
+				return nil, err
+			}
+		}
+		return &memDir{memFile: &memFile{Reader: bytes.NewReader(nil), info: s}, entries: entries}, nil
+	}
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{Reader: bytes.NewReader(content), info: s}, nil
+}
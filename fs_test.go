@@ -0,0 +1,110 @@
+package mockhttp_test
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+//go:embed testdata
+var fixtures embed.FS
+
+// unseekableFS wraps fs.FS to hide the Seek and ReadAt
+// methods of its files
+type unseekableFS struct {
+	fs.FS
+}
+
+type unseekableFile struct {
+	fs.File
+}
+
+func (fsys unseekableFS) Open(name string) (fs.File, error) {
+	f, err := fsys.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return unseekableFile{f}, nil
+}
+
+func TestFSRT(t *testing.T) {
+	testdata, err := fs.Sub(fixtures, "testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	mapFS := fstest.MapFS{
+		"test.txt":       {Data: []byte("hello world"), ModTime: time.Now()},
+		"persons/1.json": {Data: []byte(`{"id":1}`)},
+	}
+
+	fsyss := map[string]fs.FS{
+		"embed.FS":   testdata,
+		"MapFS":      mapFS,
+		"unseekable": unseekableFS{mapFS},
+	}
+
+	tests := []struct {
+		path        string
+		rangeHeader string
+		status      int
+		contentType string
+		content     string
+	}{
+		{path: "/test.txt", status: http.StatusOK, contentType: "text/plain; charset=utf-8", content: "hello world"},
+		{path: "/test.txt", rangeHeader: "bytes=6-", status: http.StatusPartialContent, contentType: "text/plain; charset=utf-8", content: "world"},
+		{path: "/../../test.txt", status: http.StatusOK, contentType: "text/plain; charset=utf-8", content: "hello world"},
+		{path: "/persons/2.json", status: http.StatusNotFound, contentType: "text/plain", content: "Not Found"},
+		{path: "/persons/", status: http.StatusForbidden, contentType: "text/plain", content: "Forbidden"},
+	}
+
+	for name, fsys := range fsyss {
+		rt := mockhttp.FSRT(fsys)
+		for i, test := range tests {
+			req, _ := http.NewRequest("GET", "https://foobar.com"+test.path, nil)
+			if test.rangeHeader != "" {
+				req.Header.Set("Range", test.rangeHeader)
+			}
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Errorf("[%s %d] unexpected error: %s", name, i, err)
+				continue
+			}
+			if want, have := test.status, resp.StatusCode; want != have {
+				t.Errorf("[%s %d] expected %#v, got %#v", name, i, want, have)
+			}
+			if want, have := test.contentType, resp.Header.Get("Content-Type"); want != have {
+				t.Errorf("[%s %d] expected %#v, got %#v", name, i, want, have)
+			}
+			content, _ := ioutil.ReadAll(resp.Body)
+			if want, have := test.content, string(content); want != have {
+				t.Errorf("[%s %d] expected %#v, got %#v", name, i, want, have)
+			}
+		}
+	}
+}
+
+func TestFSRT_writable(t *testing.T) {
+	rt := mockhttp.FSRT(fstest.MapFS{}, mockhttp.FileWritable())
+	req, _ := http.NewRequest("PUT", "https://foobar.com/hello.txt", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req, _ = http.NewRequest("GET", "https://foobar.com/hello.txt", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if n, _ := io.Copy(ioutil.Discard, resp.Body); n != 0 {
+		t.Errorf("expected empty file, got %d bytes", n)
+	}
+}
@@ -0,0 +1,134 @@
+package mockhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// bytesResponse builds a response of the given status with the content
+// as body. Content-Length and Date header will be set accordingly.
+func bytesResponse(r *http.Request, status int, contentType string, content []byte) *http.Response {
+	size := int64(len(content))
+
+	// mock header
+	header := make(http.Header)
+	header.Add("Content-Length", fmt.Sprintf("%d", size))
+	header.Add("Content-Type", contentType)
+	header.Add("Date", time.Now().Format(time.RFC1123))
+
+	// mock response
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         r.Proto,
+		ProtoMajor:    r.ProtoMajor,
+		ProtoMinor:    r.ProtoMinor,
+		ContentLength: size,
+		Request:       r,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(content)),
+	}
+}
+
+// marshaledResponseRT returns a RoundTripperFunc that always responds
+// the given content. If err is not nil, the RoundTripperFunc will return
+// the error as transport error instead.
+func marshaledResponseRT(status int, contentType string, content []byte, err error) RoundTripperFunc {
+	if err != nil {
+		return TransportErrorRT(fmt.Errorf("error marshaling response: %s", err))
+	}
+	return func(r *http.Request) (*http.Response, error) {
+		return bytesResponse(r, status, contentType, content), nil
+	}
+}
+
+// JSONResponseRT returns an http.RoundTripper that always responds
+// the JSON encoding of v with the given status code.
+//
+// v is marshaled once when the RoundTripper is created. If v cannot
+// be marshaled, the RoundTripper will always return the marshal error.
+func JSONResponseRT(status int, v interface{}) RoundTripperFunc {
+	content, err := json.Marshal(v)
+	return marshaledResponseRT(status, "application/json; charset=utf-8", content, err)
+}
+
+// JSONIndentResponseRT is like JSONResponseRT but applies
+// json.MarshalIndent to format the output.
+func JSONIndentResponseRT(status int, v interface{}, prefix, indent string) RoundTripperFunc {
+	content, err := json.MarshalIndent(v, prefix, indent)
+	return marshaledResponseRT(status, "application/json; charset=utf-8", content, err)
+}
+
+// XMLResponseRT returns an http.RoundTripper that always responds
+// the XML encoding of v, with the standard xml.Header, and the
+// given status code.
+//
+// v is marshaled once when the RoundTripper is created. If v cannot
+// be marshaled, the RoundTripper will always return the marshal error.
+func XMLResponseRT(status int, v interface{}) RoundTripperFunc {
+	content, err := xml.Marshal(v)
+	return marshaledResponseRT(status, "application/xml; charset=utf-8",
+		append([]byte(xml.Header), content...), err)
+}
+
+// XMLIndentResponseRT is like XMLResponseRT but applies
+// xml.MarshalIndent to format the output.
+func XMLIndentResponseRT(status int, v interface{}, prefix, indent string) RoundTripperFunc {
+	content, err := xml.MarshalIndent(v, prefix, indent)
+	return marshaledResponseRT(status, "application/xml; charset=utf-8",
+		append([]byte(xml.Header), content...), err)
+}
+
+// Problem represents a problem details object for HTTP APIs
+// as defined in RFC 7807.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extensions are additional members of the problem
+	// details object. They are marshaled alongside the
+	// standard members.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem // prevent recursion
+	content, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return content, err
+	}
+
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	if err = json.Unmarshal(content, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// ProblemResponseRT returns an http.RoundTripper that always responds
+// the problem details (RFC 7807) as "application/problem+json".
+//
+// The status code of the response is p.Status, or 500 if not set.
+// If p.Title is empty, the status text of the status code will be used.
+func ProblemResponseRT(p Problem) RoundTripperFunc {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	content, err := json.Marshal(p)
+	return marshaledResponseRT(p.Status, "application/problem+json", content, err)
+}
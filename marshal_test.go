@@ -0,0 +1,161 @@
+package mockhttp_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+type marshalItem struct {
+	ID   int    `json:"id" xml:"id,attr"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestJSONResponseRT(t *testing.T) {
+
+	var resp *http.Response
+	var err error
+	var content []byte
+
+	tests := []struct {
+		rt      http.RoundTripper
+		status  int
+		content string
+	}{
+		{
+			rt:      mockhttp.JSONResponseRT(http.StatusOK, marshalItem{ID: 1, Name: "hello"}),
+			status:  http.StatusOK,
+			content: `{"id":1,"name":"hello"}`,
+		},
+		{
+			rt:      mockhttp.JSONResponseRT(http.StatusCreated, []int{1, 2, 3}),
+			status:  http.StatusCreated,
+			content: `[1,2,3]`,
+		},
+		{
+			rt:      mockhttp.JSONIndentResponseRT(http.StatusOK, map[string]int{"a": 1}, "", "  "),
+			status:  http.StatusOK,
+			content: "{\n  \"a\": 1\n}",
+		},
+	}
+
+	for i, test := range tests {
+		client := &http.Client{Transport: test.rt}
+		if resp, err = client.Get("https://api.foobar.com/items"); err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "application/json; charset=utf-8", resp.Header.Get("Content-Type"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := fmt.Sprintf("%d", len(test.content)), resp.Header.Get("Content-Length"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if content, err = ioutil.ReadAll(resp.Body); err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+		} else if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestJSONResponseRT_error(t *testing.T) {
+	client := &http.Client{
+		Transport: mockhttp.JSONResponseRT(http.StatusOK, make(chan int)),
+	}
+	resp, err := client.Get("https://api.foobar.com/items")
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	if resp != nil {
+		t.Errorf("expected nil, got %#v", resp)
+	}
+}
+
+func TestXMLResponseRT(t *testing.T) {
+	client := &http.Client{
+		Transport: mockhttp.XMLResponseRT(http.StatusAccepted, marshalItem{ID: 1, Name: "hello"}),
+	}
+	resp, err := client.Get("https://api.foobar.com/items/1")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+
+	if want, have := http.StatusAccepted, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "application/xml; charset=utf-8", resp.Header.Get("Content-Type"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"+
+		`<marshalItem id="1"><name>hello</name></marshalItem>`, string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := int64(len(content)), resp.ContentLength; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestProblemResponseRT(t *testing.T) {
+	client := &http.Client{
+		Transport: mockhttp.ProblemResponseRT(mockhttp.Problem{
+			Type:   "https://example.com/probs/out-of-credit",
+			Status: http.StatusForbidden,
+			Detail: "Your current balance is 30, but that costs 50.",
+			Extensions: map[string]interface{}{
+				"balance": 30,
+			},
+		}),
+	}
+	resp, err := client.Get("https://api.foobar.com/account/12345/msgs/abc")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if want, have := http.StatusForbidden, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "application/problem+json", resp.Header.Get("Content-Type"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	var problem map[string]interface{}
+	if err = json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if want, have := "Forbidden", problem["title"]; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := float64(403), problem["status"]; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := float64(30), problem["balance"]; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func ExampleJSONResponseRT() {
+	client := &http.Client{
+		Transport: mockhttp.JSONResponseRT(http.StatusOK, map[string]interface{}{
+			"status": "OK",
+			"cool":   true,
+		}),
+	}
+
+	resp, _ := client.Get("https://api.service1.com/some/endpoint")
+	content, _ := ioutil.ReadAll(resp.Body)
+	fmt.Printf("%s\n", content)
+
+	// Output: {"cool":true,"status":"OK"}
+}
@@ -0,0 +1,239 @@
+package mockhttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RequestMatcher reports if the given request matches certain
+// criteria.
+//
+// Matchers that inspect the request body will restore the body
+// after reading, so the request can still be consumed by other
+// matchers and the downstream http.RoundTripper.
+type RequestMatcher func(r *http.Request) bool
+
+// MatchAll returns a RequestMatcher that matches if all of the
+// matchers match. Matchers are evaluated in order and stop at
+// the first mismatch.
+func MatchAll(matchers ...RequestMatcher) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, matcher := range matchers {
+			if !matcher(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny returns a RequestMatcher that matches if any of the
+// matchers match. Matchers are evaluated in order and stop at
+// the first match.
+func MatchAny(matchers ...RequestMatcher) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, matcher := range matchers {
+			if matcher(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchNot returns a RequestMatcher that negates the given matcher.
+func MatchNot(matcher RequestMatcher) RequestMatcher {
+	return func(r *http.Request) bool {
+		return !matcher(r)
+	}
+}
+
+// MatchMethod matches requests of the given method.
+func MatchMethod(method string) RequestMatcher {
+	return func(r *http.Request) bool {
+		return strings.EqualFold(r.Method, method)
+	}
+}
+
+// MatchPath matches requests of the given URL path.
+func MatchPath(path string) RequestMatcher {
+	return func(r *http.Request) bool {
+		return r.URL.Path == path
+	}
+}
+
+// MatchPathPattern matches requests with URL path matching the
+// pattern. See TemplateResponse.PathPattern for the pattern syntax.
+func MatchPathPattern(pattern string) RequestMatcher {
+	return func(r *http.Request) bool {
+		_, ok := matchPathPattern(pattern, r.URL.Path)
+		return ok
+	}
+}
+
+// MatchHeader matches requests with the given header value.
+func MatchHeader(key, value string) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, v := range r.Header[http.CanonicalHeaderKey(key)] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// readBody reads the whole request body and restores it so the
+// body may be read again by others.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	content, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(content))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	}
+	return content, err
+}
+
+// MatchFormValue matches requests with URL encoded body
+// (application/x-www-form-urlencoded) that has the
+// given value for the key. URL query is not considered.
+func MatchFormValue(key, value string) RequestMatcher {
+	return func(r *http.Request) bool {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/x-www-form-urlencoded" {
+			return false
+		}
+		content, err := readBody(r)
+		if err != nil {
+			return false
+		}
+		values, err := url.ParseQuery(string(content))
+		if err != nil {
+			return false
+		}
+		for _, v := range values[key] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// multipartForm parses the request body as multipart/form-data
+// and restores the body.
+func multipartForm(r *http.Request) (*multipart.Form, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, fmt.Errorf("request is not multipart/form-data")
+	}
+	content, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	return multipart.NewReader(bytes.NewReader(content), params["boundary"]).
+		ReadForm(int64(len(content)))
+}
+
+// MatchMultipartValue matches multipart/form-data requests
+// that has a non-file part of the given name and value.
+func MatchMultipartValue(name, value string) RequestMatcher {
+	return func(r *http.Request) bool {
+		form, err := multipartForm(r)
+		if err != nil {
+			return false
+		}
+		defer form.RemoveAll()
+		for _, v := range form.Value[name] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchMultipartFile matches multipart/form-data requests
+// that has a file part of the given name. If filename is
+// not empty, the file part should also have the filename.
+func MatchMultipartFile(name, filename string) RequestMatcher {
+	return func(r *http.Request) bool {
+		form, err := multipartForm(r)
+		if err != nil {
+			return false
+		}
+		defer form.RemoveAll()
+		for _, fh := range form.File[name] {
+			if filename == "" || fh.Filename == filename {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RequestMux routes requests to the http.RoundTripper of the
+// first matching RequestMatcher, in the order they were added.
+//
+// RequestMux can be used with MuxRoundTripper to distinguish
+// different requests to the same host.
+type RequestMux struct {
+	rules    []requestRule
+	fallback http.RoundTripper
+}
+
+type requestRule struct {
+	matcher RequestMatcher
+	rt      http.RoundTripper
+}
+
+// NewRequestMux returns a new RequestMux
+func NewRequestMux() *RequestMux {
+	return &RequestMux{}
+}
+
+// Add an http.RoundTripper to the mux for requests matched
+// by the matcher.
+func (mux *RequestMux) Add(matcher RequestMatcher, rt http.RoundTripper) {
+	mux.rules = append(mux.rules, requestRule{matcher, rt})
+}
+
+// AddFunc add a RoundTripperFunc to the mux for requests
+// matched by the matcher.
+func (mux *RequestMux) AddFunc(matcher RequestMatcher, fn RoundTripperFunc) {
+	mux.Add(matcher, fn)
+}
+
+// Fallback sets the http.RoundTripper for requests that matches
+// none of the matchers.
+func (mux *RequestMux) Fallback(rt http.RoundTripper) {
+	mux.fallback = rt
+}
+
+// RoundTrip implements http.RoundTripper
+func (mux *RequestMux) RoundTrip(r *http.Request) (*http.Response, error) {
+	for _, rule := range mux.rules {
+		if rule.matcher(r) {
+			return rule.rt.RoundTrip(r)
+		}
+	}
+	if mux.fallback != nil {
+		return mux.fallback.RoundTrip(r)
+	}
+	return nil, fmt.Errorf("no http.RoundTripper matches request %s %s",
+		r.Method, r.URL)
+}
@@ -0,0 +1,227 @@
+package mockhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// normalizeJSON converts v into the generic form decoded
+// by encoding/json (i.e. map[string]interface{}, []interface{},
+// float64, string, bool and nil).
+func normalizeJSON(v interface{}) (interface{}, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(content, &normalized)
+	return normalized, err
+}
+
+// jsonBody decodes the request body as JSON and restores the body.
+func jsonBody(r *http.Request) (v interface{}, err error) {
+	content, err := readBody(r)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &v)
+	return
+}
+
+// jsonMatcher returns a RequestMatcher that decodes the request body
+// as JSON and compare it with the normalized expected value.
+func jsonMatcher(expected interface{}, compare func(expected, actual interface{}) bool) RequestMatcher {
+	normalized, err := normalizeJSON(expected)
+	if err != nil {
+		panic(fmt.Sprintf("mockhttp: expected value is not JSON marshalable: %s", err))
+	}
+	return func(r *http.Request) bool {
+		actual, err := jsonBody(r)
+		if err != nil {
+			return false
+		}
+		return compare(normalized, actual)
+	}
+}
+
+// MatchJSONBody matches requests with JSON body that equals to
+// the JSON encoding of v. Key orders and white spaces are ignored.
+//
+// MatchJSONBody panics if v is not JSON marshalable.
+func MatchJSONBody(v interface{}) RequestMatcher {
+	return jsonMatcher(v, reflect.DeepEqual)
+}
+
+// MatchJSONSubset matches requests with JSON body that contains
+// the JSON encoding of v:
+//
+//   - an object contains another if it has all of its members
+//     and the member values contain the other's values;
+//   - an array contains another if every element of the other
+//     is contained by some element in the array;
+//   - other values contain another if they are equal.
+//
+// MatchJSONSubset panics if v is not JSON marshalable.
+func MatchJSONSubset(v interface{}) RequestMatcher {
+	return jsonMatcher(v, jsonContains)
+}
+
+// jsonContains reports if actual contains expected. Both values
+// should be in the generic form decoded by encoding/json.
+func jsonContains(expected, actual interface{}) bool {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, ev := range e {
+			av, found := a[key]
+			if !found || !jsonContains(ev, av) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			return false
+		}
+	nextElement:
+		for _, ev := range e {
+			for _, av := range a {
+				if jsonContains(ev, av) {
+					continue nextElement
+				}
+			}
+			return false
+		}
+		return true
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// MatchJSONPath matches requests with JSON body that, evaluating
+// the JSONPath expression, results in a value equals to the JSON
+// encoding of v. If the expression results in multiple values,
+// the request matches if any of them equals.
+//
+// Only a subset of JSONPath is supported: the root ("$"), child
+// members (".name" or "['name']"), array indexes ("[0]", negative
+// index counts from the end) and wildcards (".*" or "[*]").
+//
+// MatchJSONPath panics if the expression is invalid or v is not
+// JSON marshalable.
+func MatchJSONPath(expr string, v interface{}) RequestMatcher {
+	path, err := parseJSONPath(expr)
+	if err != nil {
+		panic(fmt.Sprintf("mockhttp: %s", err))
+	}
+	return jsonMatcher(v, func(expected, actual interface{}) bool {
+		for _, result := range path.eval(actual) {
+			if reflect.DeepEqual(expected, result) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// jsonPathStep is a single step of a parsed JSONPath. A step
+// selects either a member by name, an element by index, or all
+// children when wildcard is true.
+type jsonPathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+type jsonPath []jsonPathStep
+
+// parseJSONPath parses the supported JSONPath subset.
+func parseJSONPath(expr string) (path jsonPath, err error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %#v: must start with \"$\"", expr)
+	}
+	rest := expr[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "" {
+				return nil, fmt.Errorf("invalid JSONPath %#v: empty member name", expr)
+			}
+			if name == "*" {
+				path = append(path, jsonPathStep{wildcard: true})
+			} else {
+				path = append(path, jsonPathStep{name: name})
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %#v: unclosed bracket", expr)
+			}
+			selector := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if selector == "*" {
+				path = append(path, jsonPathStep{wildcard: true})
+			} else if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') &&
+				selector[len(selector)-1] == selector[0] {
+				path = append(path, jsonPathStep{name: selector[1 : len(selector)-1]})
+			} else if index, err := strconv.Atoi(selector); err == nil {
+				path = append(path, jsonPathStep{index: index, isIndex: true})
+			} else {
+				return nil, fmt.Errorf("invalid JSONPath %#v: unsupported selector %#v", expr, selector)
+			}
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %#v: unexpected %#v", expr, rest[:1])
+		}
+	}
+	return
+}
+
+// eval evaluates the path against the decoded JSON value
+// and returns all the resulting values.
+func (path jsonPath) eval(v interface{}) []interface{} {
+	results := []interface{}{v}
+	for _, step := range path {
+		var next []interface{}
+		for _, result := range results {
+			switch node := result.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					for _, child := range node {
+						next = append(next, child)
+					}
+				} else if child, found := node[step.name]; found && !step.isIndex {
+					next = append(next, child)
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, node...)
+				} else if step.isIndex {
+					index := step.index
+					if index < 0 {
+						index += len(node)
+					}
+					if index >= 0 && index < len(node) {
+						next = append(next, node[index])
+					}
+				}
+			}
+		}
+		results = next
+	}
+	return results
+}
@@ -0,0 +1,297 @@
+package mockhttp_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func mustRequest(method, url, contentType, body string) *http.Request {
+	r, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestRequestMatcher_body(t *testing.T) {
+
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("title", "hello")
+	fw, _ := mw.CreateFormFile("upload", "hello.txt")
+	fmt.Fprint(fw, "hello world")
+	mw.Close()
+
+	jsonReq := `{"method": "user.get", "params": {"id": 1, "tags": ["a", "b"]}}`
+	xmlReq := `<?xml version="1.0"?>
+<methodCall>
+  <methodName>user.get</methodName>
+  <params><param id="1"><value>42</value></param><param id="2"><value>43</value></param></params>
+</methodCall>`
+
+	tests := []struct {
+		desc        string
+		matcher     mockhttp.RequestMatcher
+		contentType string
+		body        string
+		match       bool
+	}{
+		{
+			desc:        "JSON equality",
+			matcher:     mockhttp.MatchJSONBody(map[string]interface{}{"params": map[string]interface{}{"tags": []string{"a", "b"}, "id": 1}, "method": "user.get"}),
+			contentType: "application/json",
+			body:        jsonReq,
+			match:       true,
+		},
+		{
+			desc:        "JSON inequality",
+			matcher:     mockhttp.MatchJSONBody(map[string]interface{}{"method": "user.get"}),
+			contentType: "application/json",
+			body:        jsonReq,
+			match:       false,
+		},
+		{
+			desc:        "JSON subset",
+			matcher:     mockhttp.MatchJSONSubset(map[string]interface{}{"params": map[string]interface{}{"tags": []string{"b"}}}),
+			contentType: "application/json",
+			body:        jsonReq,
+			match:       true,
+		},
+		{
+			desc:        "JSON not subset",
+			matcher:     mockhttp.MatchJSONSubset(map[string]interface{}{"params": map[string]interface{}{"tags": []string{"c"}}}),
+			contentType: "application/json",
+			body:        jsonReq,
+			match:       false,
+		},
+		{
+			desc:        "invalid JSON",
+			matcher:     mockhttp.MatchJSONSubset(map[string]interface{}{}),
+			contentType: "application/json",
+			body:        `{"method": `,
+			match:       false,
+		},
+		{
+			desc:    "JSONPath member",
+			matcher: mockhttp.MatchJSONPath("$.params.id", 1),
+			body:    jsonReq,
+			match:   true,
+		},
+		{
+			desc:    "JSONPath index",
+			matcher: mockhttp.MatchJSONPath("$['params'].tags[-1]", "b"),
+			body:    jsonReq,
+			match:   true,
+		},
+		{
+			desc:    "JSONPath wildcard",
+			matcher: mockhttp.MatchJSONPath("$.params.tags[*]", "a"),
+			body:    jsonReq,
+			match:   true,
+		},
+		{
+			desc:    "JSONPath mismatch",
+			matcher: mockhttp.MatchJSONPath("$.params.id", 2),
+			body:    jsonReq,
+			match:   false,
+		},
+		{
+			desc:        "form value",
+			matcher:     mockhttp.MatchFormValue("action", "login"),
+			contentType: "application/x-www-form-urlencoded",
+			body:        url.Values{"action": {"login"}, "user": {"foo"}}.Encode(),
+			match:       true,
+		},
+		{
+			desc:        "form value of other content type",
+			matcher:     mockhttp.MatchFormValue("action", "login"),
+			contentType: "text/plain",
+			body:        "action=login",
+			match:       false,
+		},
+		{
+			desc:        "multipart value",
+			matcher:     mockhttp.MatchMultipartValue("title", "hello"),
+			contentType: mw.FormDataContentType(),
+			body:        multipartBody.String(),
+			match:       true,
+		},
+		{
+			desc:        "multipart file",
+			matcher:     mockhttp.MatchMultipartFile("upload", "hello.txt"),
+			contentType: mw.FormDataContentType(),
+			body:        multipartBody.String(),
+			match:       true,
+		},
+		{
+			desc:        "multipart file of other filename",
+			matcher:     mockhttp.MatchMultipartFile("upload", "world.txt"),
+			contentType: mw.FormDataContentType(),
+			body:        multipartBody.String(),
+			match:       false,
+		},
+		{
+			desc:    "XPath text",
+			matcher: mockhttp.MatchXPath("/methodCall/methodName", "user.get"),
+			body:    xmlReq,
+			match:   true,
+		},
+		{
+			desc:    "XPath descendant with attribute predicate",
+			matcher: mockhttp.MatchXPath("//param[@id='2']/value", "43"),
+			body:    xmlReq,
+			match:   true,
+		},
+		{
+			desc:    "XPath position and attribute",
+			matcher: mockhttp.MatchXPath("/methodCall/params/param[1]/@id", "1"),
+			body:    xmlReq,
+			match:   true,
+		},
+		{
+			desc:    "XPath descendant position per parent",
+			matcher: mockhttp.MatchXPath("//item[1]", "b"),
+			body:    "<list><group><item>a</item></group><group><item>b</item></group></list>",
+			match:   true,
+		},
+		{
+			desc:    "XPath descendant position out of parent",
+			matcher: mockhttp.MatchXPath("//item[2]", "b"),
+			body:    "<list><group><item>a</item></group><group><item>b</item></group></list>",
+			match:   false,
+		},
+		{
+			desc:    "XPath descendant attribute",
+			matcher: mockhttp.MatchXPath("//@id", "1"),
+			body:    `<a><b id="1"/></a>`,
+			match:   true,
+		},
+		{
+			desc:    "XPath descendant text",
+			matcher: mockhttp.MatchXPath("//text()", "hello"),
+			body:    "<a><b>hello</b><c>world</c></a>",
+			match:   true,
+		},
+		{
+			desc:    "XPath descendant attribute under step",
+			matcher: mockhttp.MatchXPath("/a/c//@id", "1"),
+			body:    `<a><b id="1"/><c/></a>`,
+			match:   false,
+		},
+		{
+			desc:    "XPath mismatch",
+			matcher: mockhttp.MatchXPath("/methodCall/params/param[2]/value/text()", "42"),
+			body:    xmlReq,
+			match:   false,
+		},
+	}
+
+	for i, test := range tests {
+		r := mustRequest("POST", "https://rpc.foobar.com/", test.contentType, test.body)
+		if want, have := test.match, test.matcher(r); want != have {
+			t.Errorf("[%d] %s: expected %#v, got %#v", i, test.desc, want, have)
+		}
+
+		// body should be restored for downstream reader
+		content, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("[%d] %s: unexpected error: %s", i, test.desc, err)
+		} else if want, have := test.body, string(content); want != have {
+			t.Errorf("[%d] %s: expected %#v, got %#v", i, test.desc, want, have)
+		}
+	}
+}
+
+func TestRequestMux(t *testing.T) {
+	mux := mockhttp.NewRequestMux()
+	mux.Add(
+		mockhttp.MatchAll(mockhttp.MatchMethod("POST"), mockhttp.MatchJSONPath("$.method", "user.get")),
+		mockhttp.StaticResponseRT("user", "text/plain"),
+	)
+	mux.Add(
+		mockhttp.MatchAll(mockhttp.MatchMethod("POST"), mockhttp.MatchJSONPath("$.method", "user.delete")),
+		mockhttp.StaticResponseRT("deleted", "text/plain"),
+	)
+	mux.AddFunc(
+		mockhttp.MatchAny(mockhttp.MatchPath("/echo"), mockhttp.MatchHeader("X-Echo", "1")),
+		func(r *http.Request) (*http.Response, error) {
+			content, _ := ioutil.ReadAll(r.Body)
+			return mockhttp.StaticResponseRT(string(content), "text/plain")(r)
+		},
+	)
+
+	client := &http.Client{Transport: mux}
+	tests := []struct {
+		path    string
+		body    string
+		content string
+	}{
+		{path: "/rpc", body: `{"method": "user.get"}`, content: "user"},
+		{path: "/rpc", body: `{"method": "user.delete"}`, content: "deleted"},
+		{path: "/echo", body: `{"method": "user.update"}`, content: `{"method": "user.update"}`},
+	}
+
+	for i, test := range tests {
+		resp, err := client.Post("https://rpc.foobar.com"+test.path, "application/json", strings.NewReader(test.body))
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+
+	// no match without fallback
+	if _, err := client.Post("https://rpc.foobar.com/rpc", "application/json", strings.NewReader(`{}`)); err == nil {
+		t.Errorf("expected error, got nil")
+	} else if want, have := "no http.RoundTripper matches request POST https://rpc.foobar.com/rpc", err.(*url.Error).Err.Error(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// no match with fallback
+	mux.Fallback(mockhttp.ServerErrorRT(http.StatusNotImplemented))
+	if resp, err := client.Post("https://rpc.foobar.com/rpc", "application/json", strings.NewReader(`{}`)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if want, have := http.StatusNotImplemented, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func ExampleRequestMux() {
+	rpc := mockhttp.NewRequestMux()
+	rpc.Add(mockhttp.MatchJSONPath("$.method", "user.get"),
+		mockhttp.StaticResponseRT(`{"id": 1}`, "application/json"))
+	rpc.Add(mockhttp.MatchJSONPath("$.method", "user.delete"),
+		mockhttp.ServerErrorRT(http.StatusForbidden))
+
+	mock := mockhttp.NewMuxRoundTripper()
+	mock.Add("rpc.service1.com", rpc)
+	client := mock.NewClient()
+
+	resp, _ := client.Post("https://rpc.service1.com/", "application/json",
+		strings.NewReader(`{"method": "user.get", "params": [1]}`))
+	content, _ := ioutil.ReadAll(resp.Body)
+	fmt.Printf("result 1: %s\n", content)
+
+	resp, _ = client.Post("https://rpc.service1.com/", "application/json",
+		strings.NewReader(`{"method": "user.delete", "params": [1]}`))
+	content, _ = ioutil.ReadAll(resp.Body)
+	fmt.Printf("result 2: %s\n", content)
+
+	// Output:
+	// result 1: {"id": 1}
+	// result 2: Forbidden
+}
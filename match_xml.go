@@ -0,0 +1,275 @@
+package mockhttp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is a minimal element tree for XPath evaluation.
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     bytes.Buffer // all descendant character data
+}
+
+// parseXMLTree parses the content into a tree. The returned node is
+// the document root, with the document element as its only child.
+func parseXMLTree(content []byte) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			for _, node := range stack {
+				node.text.Write(t)
+			}
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("no XML element found")
+	}
+	return root, nil
+}
+
+// xpathStep is a single location step of a parsed XPath.
+type xpathStep struct {
+	descendant bool   // step is preceded by "//"
+	name       string // element name, or "*"
+	attr       string // attribute step ("@name"), only allowed as last step
+	text       bool   // text() step, only allowed as last step
+	position   int    // positional predicate ("[n]"), 1-based
+	predAttr   string // attribute predicate ("[@name='value']")
+	predValue  string
+}
+
+type xpath []xpathStep
+
+// parseXPath parses the supported XPath subset.
+func parseXPath(expr string) (path xpath, err error) {
+	if !strings.HasPrefix(expr, "/") {
+		return nil, fmt.Errorf("invalid XPath %#v: must be an absolute path", expr)
+	}
+
+	// split expression into steps, respecting brackets and quotes
+	var steps []string
+	var current strings.Builder
+	depth, quote := 0, byte(0)
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '/' && depth == 0:
+			steps = append(steps, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	steps = append(steps, current.String())
+	steps = steps[1:] // the empty string before leading "/"
+
+	descendant := false
+	for i, s := range steps {
+		if s == "" {
+			if descendant || i == len(steps)-1 {
+				return nil, fmt.Errorf("invalid XPath %#v: empty step", expr)
+			}
+			descendant = true
+			continue
+		}
+		step := xpathStep{descendant: descendant}
+		descendant = false
+
+		if strings.HasPrefix(s, "@") || s == "text()" {
+			if i != len(steps)-1 {
+				return nil, fmt.Errorf("invalid XPath %#v: %#v must be the last step", expr, s)
+			}
+			step.text = s == "text()"
+			step.attr = strings.TrimPrefix(s, "@")
+			if step.text {
+				step.attr = ""
+			}
+			path = append(path, step)
+			continue
+		}
+
+		if open := strings.Index(s, "["); open != -1 {
+			if !strings.HasSuffix(s, "]") {
+				return nil, fmt.Errorf("invalid XPath %#v: malformed predicate in %#v", expr, s)
+			}
+			predicate := strings.TrimSpace(s[open+1 : len(s)-1])
+			s = s[:open]
+			if position, err := strconv.Atoi(predicate); err == nil && position > 0 {
+				step.position = position
+			} else if eq := strings.Index(predicate, "="); strings.HasPrefix(predicate, "@") && eq != -1 {
+				step.predAttr = strings.TrimSpace(predicate[1:eq])
+				step.predValue = strings.Trim(strings.TrimSpace(predicate[eq+1:]), `'"`)
+			} else {
+				return nil, fmt.Errorf("invalid XPath %#v: unsupported predicate %#v", expr, predicate)
+			}
+		}
+		step.name = s
+		path = append(path, step)
+	}
+	return
+}
+
+// matchStep reports if the element node satisfies the name
+// and attribute predicate of the step.
+func (step xpathStep) matchStep(node *xmlNode) bool {
+	if step.name != "*" && step.name != node.name {
+		return false
+	}
+	if step.predAttr != "" {
+		for _, attr := range node.attrs {
+			if attr.Name.Local == step.predAttr && attr.Value == step.predValue {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// descendants returns all the descendant elements of the node
+// in document order.
+func (node *xmlNode) descendants() (nodes []*xmlNode) {
+	for _, child := range node.children {
+		nodes = append(nodes, child)
+		nodes = append(nodes, child.descendants()...)
+	}
+	return
+}
+
+// eval evaluates the path against the document root and returns
+// the string values of all resulting nodes.
+func (path xpath) eval(root *xmlNode) (values []string) {
+	nodes := []*xmlNode{root}
+	for _, step := range path {
+		if step.descendant && (step.text || step.attr != "") {
+			// "//@id" and "//text()" select from the nodes and all
+			// their descendants
+			var expanded []*xmlNode
+			seen := make(map[*xmlNode]bool)
+			for _, node := range nodes {
+				for _, n := range append([]*xmlNode{node}, node.descendants()...) {
+					if !seen[n] {
+						seen[n] = true
+						expanded = append(expanded, n)
+					}
+				}
+			}
+			nodes = expanded
+		}
+		if step.text {
+			for _, node := range nodes {
+				values = append(values, node.text.String())
+			}
+			return
+		}
+		if step.attr != "" {
+			for _, node := range nodes {
+				for _, attr := range node.attrs {
+					if attr.Name.Local == step.attr {
+						values = append(values, attr.Value)
+					}
+				}
+			}
+			return
+		}
+
+		// positions are counted among the children of each parent,
+		// so "//" steps select from the node and all its descendants
+		var next []*xmlNode
+		seen := make(map[*xmlNode]bool)
+		for _, node := range nodes {
+			parents := []*xmlNode{node}
+			if step.descendant {
+				parents = append(parents, node.descendants()...)
+			}
+			for _, parent := range parents {
+				count := 0
+				for _, candidate := range parent.children {
+					if !step.matchStep(candidate) {
+						continue
+					}
+					count++
+					if (step.position == 0 || step.position == count) && !seen[candidate] {
+						seen[candidate] = true
+						next = append(next, candidate)
+					}
+				}
+			}
+		}
+		nodes = next
+	}
+	for _, node := range nodes {
+		values = append(values, node.text.String())
+	}
+	return
+}
+
+// MatchXPath matches requests with XML body that, evaluating the
+// XPath expression, results in a node of the given string value.
+// Leading and trailing white spaces of the node value are ignored.
+//
+// Only a subset of XPath is supported: absolute location paths
+// with child ("/") and descendant ("//") steps, element names or
+// "*", positional ("[1]") and attribute ("[@id='1']") predicates,
+// and a final attribute ("@id") or "text()" step, which may also be
+// a descendant step (e.g. "//@id"). Positions count the matching
+// children of each parent, so "//item[1]" selects the first item of
+// every parent.
+//
+// MatchXPath panics if the expression is invalid.
+func MatchXPath(expr, value string) RequestMatcher {
+	path, err := parseXPath(expr)
+	if err != nil {
+		panic(fmt.Sprintf("mockhttp: %s", err))
+	}
+	return func(r *http.Request) bool {
+		content, err := readBody(r)
+		if err != nil {
+			return false
+		}
+		root, err := parseXMLTree(content)
+		if err != nil {
+			return false
+		}
+		for _, v := range path.eval(root) {
+			if strings.TrimSpace(v) == value {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,186 @@
+package mockhttp
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileWritable makes FileSystemRT accept PUT and DELETE requests.
+// Written and deleted files are kept in an in-memory overlay of the
+// folder, which lives as long as the http.RoundTripper. Files in the
+// folder are never modified.
+//
+// PUT creates or replaces the file with the request body, and is
+// answered with 201 (Created) or 204 (No Content). DELETE removes the
+// file and is answered with 204 (No Content), or 404 (Not Found) if
+// the file does not exist. Both are answered with 409 (Conflict) for
+// directories, and with 412 (Precondition Failed) if the request has
+// a matching If-None-Match header (e.g. "If-None-Match: *" to only
+// create new files).
+func FileWritable() FileSystemOption {
+	return func(config *fileSystemConfig) {
+		config.overlay = &fileOverlay{
+			files: make(map[string]*overlayFile),
+		}
+	}
+}
+
+// overlayFile is a file written to or deleted from the overlay.
+type overlayFile struct {
+	name    string
+	content []byte
+	modTime time.Time
+	deleted bool
+}
+
+// fileOverlay is the in-memory overlay of files of FileSystemRT
+type fileOverlay struct {
+	lock  sync.RWMutex
+	files map[string]*overlayFile
+}
+
+// overlayKey returns the cleaned request path as the overlay key
+func overlayKey(name string) string {
+	return path.Clean("/" + name)
+}
+
+// open the file of name in the overlay. It reports found as false if
+// the file is neither written nor deleted.
+func (overlay *fileOverlay) open(name string) (f file, found bool, err error) {
+	overlay.lock.RLock()
+	defer overlay.lock.RUnlock()
+	of, found := overlay.files[overlayKey(name)]
+	if !found {
+		return nil, false, nil
+	}
+	if of.deleted {
+		return nil, true, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return &memFile{Reader: bytes.NewReader(of.content), info: memFileInfo{of}}, true, nil
+}
+
+// set the file of name in the overlay
+func (overlay *fileOverlay) set(name string, of *overlayFile) {
+	overlay.lock.Lock()
+	defer overlay.lock.Unlock()
+	overlay.files[overlayKey(name)] = of
+}
+
+// entries returns the written and deleted entries directly under
+// the directory of name. Directories of written files are included
+// as written entries.
+func (overlay *fileOverlay) entries(name string) (written map[string]dirEntry, deleted map[string]bool) {
+	overlay.lock.RLock()
+	defer overlay.lock.RUnlock()
+	prefix := strings.TrimSuffix(overlayKey(name), "/") + "/"
+	written, deleted = make(map[string]dirEntry), make(map[string]bool)
+	for key, of := range overlay.files {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := key[len(prefix):]
+		if i := strings.Index(rest, "/"); i != -1 {
+			if !of.deleted {
+				written[rest[:i]] = dirEntry{Name: rest[:i], IsDir: true, ModTime: of.modTime}
+			}
+			continue
+		}
+		if of.deleted {
+			deleted[rest] = true
+			continue
+		}
+		written[rest] = dirEntry{Name: rest, Size: int64(len(of.content)), ModTime: of.modTime}
+	}
+	return
+}
+
+// memFile is an in-memory file
+type memFile struct {
+	*bytes.Reader
+	info os.FileInfo
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+// memFileInfo implements os.FileInfo for overlayFile
+type memFileInfo struct {
+	of *overlayFile
+}
+
+func (fi memFileInfo) Name() string       { return fi.of.name }
+func (fi memFileInfo) Size() int64        { return int64(len(fi.of.content)) }
+func (fi memFileInfo) Mode() os.FileMode  { return 0644 }
+func (fi memFileInfo) ModTime() time.Time { return fi.of.modTime }
+func (fi memFileInfo) IsDir() bool        { return false }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+// serveWrite serves the PUT or DELETE request with the overlay
+func (config *fileSystemConfig) serveWrite(r *http.Request) (*http.Response, error) {
+	f, _, err := config.open(r.URL.Path)
+	if err != nil && openErrorStatus(err) != http.StatusNotFound {
+		return statusTextResponse(r, openErrorStatus(err)), nil
+	}
+	exists := err == nil
+	if exists {
+		s, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return statusTextResponse(r, http.StatusInternalServerError), nil
+		}
+		if s.IsDir() {
+			f.Close()
+			return statusTextResponse(r, http.StatusConflict), nil
+		}
+		etag, err := contentETag(f)
+		f.Close()
+		if err != nil {
+			return statusTextResponse(r, http.StatusInternalServerError), nil
+		}
+		if status := checkConditional(r, etag, s.ModTime()); status != 0 {
+			return statusTextResponse(r, status), nil
+		}
+	}
+
+	if r.Method == http.MethodDelete {
+		if !exists {
+			return statusTextResponse(r, http.StatusNotFound), nil
+		}
+		config.overlay.set(r.URL.Path, &overlayFile{name: path.Base(r.URL.Path), deleted: true})
+		return emptyResponse(r, http.StatusNoContent, nil), nil
+	}
+
+	var content []byte
+	if r.Body != nil {
+		if content, err = ioutil.ReadAll(r.Body); err != nil {
+			return nil, fmt.Errorf("error reading request body: %s", err)
+		}
+	}
+	of := &overlayFile{
+		name:    path.Base(r.URL.Path),
+		content: content,
+		modTime: time.Now(),
+	}
+	config.overlay.set(r.URL.Path, of)
+	etag, _ := contentETag(bytes.NewReader(content))
+
+	header := make(http.Header)
+	header.Set("ETag", etag)
+	if !exists {
+		header.Set("Location", overlayKey(r.URL.Path))
+		return emptyResponse(r, http.StatusCreated, header), nil
+	}
+	return emptyResponse(r, http.StatusNoContent, header), nil
+}
@@ -0,0 +1,160 @@
+package mockhttp_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestFileSystemRT_methods(t *testing.T) {
+	tests := []struct {
+		rt      http.RoundTripper
+		method  string
+		status  int
+		allow   string
+		length  int64
+		content string
+	}{
+		{
+			rt:      mockhttp.FileSystemRT("./testdata"),
+			method:  "GET",
+			status:  http.StatusOK,
+			length:  11,
+			content: "hello world",
+		},
+		{
+			rt:     mockhttp.FileSystemRT("./testdata"),
+			method: "HEAD",
+			status: http.StatusOK,
+			length: 11,
+		},
+		{
+			rt:     mockhttp.FileSystemRT("./testdata"),
+			method: "OPTIONS",
+			status: http.StatusNoContent,
+			allow:  "GET, HEAD, OPTIONS",
+		},
+		{
+			rt:     mockhttp.FileSystemRT("./testdata", mockhttp.FileWritable()),
+			method: "OPTIONS",
+			status: http.StatusNoContent,
+			allow:  "GET, HEAD, OPTIONS, PUT, DELETE",
+		},
+		{
+			rt:      mockhttp.FileSystemRT("./testdata"),
+			method:  "POST",
+			status:  http.StatusMethodNotAllowed,
+			allow:   "GET, HEAD, OPTIONS",
+			length:  18,
+			content: "Method Not Allowed",
+		},
+		{
+			rt:      mockhttp.FileSystemRT("./testdata"),
+			method:  "DELETE",
+			status:  http.StatusMethodNotAllowed,
+			allow:   "GET, HEAD, OPTIONS",
+			length:  18,
+			content: "Method Not Allowed",
+		},
+		{
+			rt:      mockhttp.FileSystemRT("./testdata", mockhttp.FileWritable()),
+			method:  "PATCH",
+			status:  http.StatusMethodNotAllowed,
+			allow:   "GET, HEAD, OPTIONS, PUT, DELETE",
+			length:  18,
+			content: "Method Not Allowed",
+		},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, "https://foobar.com/test.txt", nil)
+		resp, err := test.rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.allow, resp.Header.Get("Allow"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.length, resp.ContentLength; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestFileWritable(t *testing.T) {
+	rt := mockhttp.FileSystemRT("./testdata", mockhttp.FileWritable())
+
+	tests := []struct {
+		method  string
+		path    string
+		header  map[string]string
+		body    io.Reader
+		status  int
+		content string
+	}{
+		// create
+		{method: "GET", path: "/new.txt", status: http.StatusNotFound, content: "Not Found"},
+		{method: "PUT", path: "/new.txt", body: strings.NewReader("new file"), status: http.StatusCreated},
+		{method: "GET", path: "/new.txt", status: http.StatusOK, content: "new file"},
+		{method: "PUT", path: "/new.txt", header: map[string]string{"If-None-Match": "*"}, body: strings.NewReader("again"), status: http.StatusPreconditionFailed, content: "Precondition Failed"},
+		{method: "GET", path: "/new.txt", status: http.StatusOK, content: "new file"},
+
+		// replace fixture
+		{method: "PUT", path: "/test.txt", body: strings.NewReader("hello overlay"), status: http.StatusNoContent},
+		{method: "GET", path: "/test.txt", status: http.StatusOK, content: "hello overlay"},
+		{method: "GET", path: "/test.txt", header: map[string]string{"Range": "bytes=6-"}, status: http.StatusPartialContent, content: "overlay"},
+
+		// delete fixture
+		{method: "DELETE", path: "/persons/1.json", status: http.StatusNoContent},
+		{method: "GET", path: "/persons/1.json", status: http.StatusNotFound, content: "Not Found"},
+		{method: "DELETE", path: "/persons/1.json", status: http.StatusNotFound, content: "Not Found"},
+		{method: "PUT", path: "/persons/1.json", body: strings.NewReader(`{"id":1}`), status: http.StatusCreated},
+		{method: "GET", path: "/persons/1.json", status: http.StatusOK, content: `{"id":1}`},
+
+		// directories
+		{method: "PUT", path: "/persons", body: strings.NewReader("x"), status: http.StatusConflict, content: "Conflict"},
+		{method: "DELETE", path: "/persons/", status: http.StatusConflict, content: "Conflict"},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, "https://foobar.com"+test.path, test.body)
+		for key, value := range test.header {
+			req.Header.Set(key, value)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+
+	// the fixtures are not modified
+	req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+	resp, err := mockhttp.FileSystemRT("./testdata").RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := "hello world", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,270 @@
+package mockhttp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// paginationConfig is the configuration of paginated RoundTrippers
+type paginationConfig struct {
+	emptyLastPage bool
+	totalSkew     int
+}
+
+// PageOption configures the paginated RoundTrippers (LinkPaginatedRT,
+// CursorPaginatedRT and OffsetPaginatedRT) to simulate edge cases.
+type PageOption func(config *paginationConfig)
+
+// PageEmptyLast adds an empty page after the last page of items, so
+// the last page of items still links to a next page, like some APIs
+// do when the number of items is a multiple of the page size.
+func PageEmptyLast() PageOption {
+	return func(config *paginationConfig) {
+		config.emptyLastPage = true
+	}
+}
+
+// PageTotalSkew makes the reported total number of items, and the
+// last page computed from it, differ from the actual number of items
+// by n. It simulates collections modified during pagination.
+func PageTotalSkew(n int) PageOption {
+	return func(config *paginationConfig) {
+		config.totalSkew = n
+	}
+}
+
+// pagination splits a collection into pages
+type pagination struct {
+	items   []interface{}
+	perPage int
+	paginationConfig
+}
+
+// newPagination returns the pagination of items, which should be
+// a slice or an array. It panics otherwise.
+func newPagination(items interface{}, perPage int, options []PageOption) *pagination {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic(fmt.Sprintf("mockhttp: items should be a slice, not %T", items))
+	}
+	if perPage < 1 {
+		panic(fmt.Sprintf("mockhttp: invalid page size %d", perPage))
+	}
+	p := &pagination{
+		items:   make([]interface{}, v.Len()),
+		perPage: perPage,
+	}
+	for i := range p.items {
+		p.items[i] = v.Index(i).Interface()
+	}
+	for _, option := range options {
+		option(&p.paginationConfig)
+	}
+	return p
+}
+
+// total returns the reported total number of items
+func (p *pagination) total() int {
+	if total := len(p.items) + p.totalSkew; total > 0 {
+		return total
+	}
+	return 0
+}
+
+// slice returns the items from offset, at most limit, and reports
+// if there are more items (or the empty last page) after them.
+func (p *pagination) slice(offset, limit int) (items []interface{}, more bool) {
+	n := len(p.items)
+	if offset >= n {
+		return []interface{}{}, false
+	}
+	end := n
+	if limit < n-offset { // not offset+limit, which may overflow
+		end = offset + limit
+	}
+	return p.items[offset:end], end < n || p.emptyLastPage
+}
+
+// queryInt returns the integer query parameter, or the fallback if
+// not given. It reports false if the parameter is invalid.
+func queryInt(query url.Values, key string, fallback, min int) (int, bool) {
+	if query.Get(key) == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(query.Get(key))
+	return n, err == nil && n >= min
+}
+
+// pageURL returns the URL of the request with the query parameter
+// set to the value.
+func pageURL(r *http.Request, key, value string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// LinkPaginatedRT returns an http.RoundTripper that serves the items,
+// in pages of perPage items, as JSON arrays. The page is selected by
+// the "page" query parameter, starting from 1. The page size may be
+// changed by the "per_page" query parameter.
+//
+// Pages are linked by the Link header (RFC 5988) with "first",
+// "prev", "next" and "last" relations, like the GitHub API. The total
+// number of items is given in the X-Total-Count header. Pages after
+// the last page are empty.
+//
+// items should be a slice or an array (e.g. the Items of
+// ResourceSimulator). LinkPaginatedRT panics otherwise.
+func LinkPaginatedRT(items interface{}, perPage int, options ...PageOption) RoundTripperFunc {
+	p := newPagination(items, perPage, options)
+	return func(r *http.Request) (*http.Response, error) {
+		query := r.URL.Query()
+		page, ok := queryInt(query, "page", 1, 1)
+		if !ok {
+			return problemResponse(r, http.StatusBadRequest, "invalid page"), nil
+		}
+		perPage, ok := queryInt(query, "per_page", p.perPage, 1)
+		if !ok {
+			return problemResponse(r, http.StatusBadRequest, "invalid per_page"), nil
+		}
+
+		last := p.total() / perPage
+		if p.total()%perPage != 0 {
+			last++
+		}
+		if p.emptyLastPage {
+			last++
+		}
+		if last < 1 {
+			last = 1
+		}
+		offset := math.MaxInt // pages too far to count are after the items
+		if page-1 <= math.MaxInt/perPage {
+			offset = (page - 1) * perPage
+		}
+		pageItems, more := p.slice(offset, perPage)
+
+		links := []string{
+			fmt.Sprintf("<%s>; rel=\"first\"", pageURL(r, "page", "1")),
+		}
+		if page > 1 && page <= last {
+			links = append(links, fmt.Sprintf("<%s>; rel=\"prev\"", pageURL(r, "page", strconv.Itoa(page-1))))
+		}
+		if more {
+			links = append(links, fmt.Sprintf("<%s>; rel=\"next\"", pageURL(r, "page", strconv.Itoa(page+1))))
+		}
+		links = append(links, fmt.Sprintf("<%s>; rel=\"last\"", pageURL(r, "page", strconv.Itoa(last))))
+
+		resp := itemResponse(r, http.StatusOK, pageItems)
+		resp.Header.Set("Link", strings.Join(links, ", "))
+		resp.Header.Set("X-Total-Count", strconv.Itoa(p.total()))
+		return resp, nil
+	}
+}
+
+// encodeCursor encodes the offset into an opaque cursor
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// decodeCursor decodes the offset of the cursor
+func decodeCursor(cursor string) (int, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(decoded), "offset:") {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(decoded), "offset:"))
+	return offset, err == nil && offset >= 0
+}
+
+// cursorPage is the response body of CursorPaginatedRT
+type cursorPage struct {
+	Items      []interface{} `json:"items"`
+	NextCursor *string       `json:"next_cursor"`
+	HasMore    bool          `json:"has_more"`
+	Total      int           `json:"total"`
+}
+
+// CursorPaginatedRT returns an http.RoundTripper that serves the
+// items, in pages of perPage items, as JSON objects like:
+//
+//	{"items": [...], "next_cursor": "b2Zmc2V0OjI", "has_more": true, "total": 5}
+//
+// The page is selected by the opaque "cursor" query parameter, which
+// is the next_cursor of the previous page, or empty for the first
+// page. next_cursor is null on the last page. Invalid cursors are
+// answered with 400 (Bad Request). The page size may be changed by
+// the "limit" query parameter.
+//
+// items should be a slice or an array. CursorPaginatedRT panics
+// otherwise.
+func CursorPaginatedRT(items interface{}, perPage int, options ...PageOption) RoundTripperFunc {
+	p := newPagination(items, perPage, options)
+	return func(r *http.Request) (*http.Response, error) {
+		query := r.URL.Query()
+		offset := 0
+		if cursor := query.Get("cursor"); cursor != "" {
+			var ok bool
+			if offset, ok = decodeCursor(cursor); !ok {
+				return problemResponse(r, http.StatusBadRequest, "invalid cursor"), nil
+			}
+		}
+		limit, ok := queryInt(query, "limit", p.perPage, 1)
+		if !ok {
+			return problemResponse(r, http.StatusBadRequest, "invalid limit"), nil
+		}
+
+		page := cursorPage{Total: p.total()}
+		page.Items, page.HasMore = p.slice(offset, limit)
+		if page.HasMore {
+			next := encodeCursor(offset + len(page.Items))
+			page.NextCursor = &next
+		}
+		return itemResponse(r, http.StatusOK, page), nil
+	}
+}
+
+// offsetPage is the response body of OffsetPaginatedRT
+type offsetPage struct {
+	Items  []interface{} `json:"items"`
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+	Total  int           `json:"total"`
+}
+
+// OffsetPaginatedRT returns an http.RoundTripper that serves the
+// items, selected by the "offset" (default 0) and "limit" (default
+// perPage) query parameters, as JSON objects like:
+//
+//	{"items": [...], "offset": 0, "limit": 2, "total": 5}
+//
+// Offsets after the last item give empty items.
+//
+// items should be a slice or an array. OffsetPaginatedRT panics
+// otherwise.
+func OffsetPaginatedRT(items interface{}, perPage int, options ...PageOption) RoundTripperFunc {
+	p := newPagination(items, perPage, options)
+	return func(r *http.Request) (*http.Response, error) {
+		query := r.URL.Query()
+		offset, ok := queryInt(query, "offset", 0, 0)
+		if !ok {
+			return problemResponse(r, http.StatusBadRequest, "invalid offset"), nil
+		}
+		limit, ok := queryInt(query, "limit", p.perPage, 1)
+		if !ok {
+			return problemResponse(r, http.StatusBadRequest, "invalid limit"), nil
+		}
+
+		page := offsetPage{Offset: offset, Limit: limit, Total: p.total()}
+		page.Items, _ = p.slice(offset, limit)
+		return itemResponse(r, http.StatusOK, page), nil
+	}
+}
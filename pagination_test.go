@@ -0,0 +1,279 @@
+package mockhttp_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestLinkPaginatedRT(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		rt      http.RoundTripper
+		url     string
+		status  int
+		link    string
+		total   string
+		content string
+	}{
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1>; rel="first", <https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=3>; rel="last"`,
+			total:   "5",
+			content: `[1,2]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items?page=2&sort=id",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1&sort=id>; rel="first", <https://api.example.com/items?page=1&sort=id>; rel="prev", <https://api.example.com/items?page=3&sort=id>; rel="next", <https://api.example.com/items?page=3&sort=id>; rel="last"`,
+			total:   "5",
+			content: `[3,4]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items?page=3",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1>; rel="first", <https://api.example.com/items?page=2>; rel="prev", <https://api.example.com/items?page=3>; rel="last"`,
+			total:   "5",
+			content: `[5]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items?page=4",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1>; rel="first", <https://api.example.com/items?page=3>; rel="last"`,
+			total:   "5",
+			content: `[]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items?page=1&per_page=5",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1&per_page=5>; rel="first", <https://api.example.com/items?page=1&per_page=5>; rel="last"`,
+			total:   "5",
+			content: `[1,2,3,4,5]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT([]int{}, 2),
+			url:     "https://api.example.com/items",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1>; rel="first", <https://api.example.com/items?page=1>; rel="last"`,
+			total:   "0",
+			content: `[]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items[:4], 2, mockhttp.PageEmptyLast()),
+			url:     "https://api.example.com/items?page=2",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1>; rel="first", <https://api.example.com/items?page=1>; rel="prev", <https://api.example.com/items?page=3>; rel="next", <https://api.example.com/items?page=3>; rel="last"`,
+			total:   "4",
+			content: `[3,4]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items[:4], 2, mockhttp.PageEmptyLast()),
+			url:     "https://api.example.com/items?page=3",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1>; rel="first", <https://api.example.com/items?page=2>; rel="prev", <https://api.example.com/items?page=3>; rel="last"`,
+			total:   "4",
+			content: `[]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2, mockhttp.PageTotalSkew(2)),
+			url:     "https://api.example.com/items?page=3",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1>; rel="first", <https://api.example.com/items?page=2>; rel="prev", <https://api.example.com/items?page=4>; rel="last"`,
+			total:   "7",
+			content: `[5]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items?page=4611686018427387905&per_page=2",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1&per_page=2>; rel="first", <https://api.example.com/items?page=3&per_page=2>; rel="last"`,
+			total:   "5",
+			content: `[]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items?page=2&per_page=9223372036854775807",
+			status:  http.StatusOK,
+			link:    `<https://api.example.com/items?page=1&per_page=9223372036854775807>; rel="first", <https://api.example.com/items?page=1&per_page=9223372036854775807>; rel="last"`,
+			total:   "5",
+			content: `[]`,
+		},
+		{
+			rt:      mockhttp.LinkPaginatedRT(items, 2),
+			url:     "https://api.example.com/items?page=0",
+			status:  http.StatusBadRequest,
+			content: `{"title":"Bad Request","status":400,"detail":"invalid page"}`,
+		},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		resp, err := test.rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.link, resp.Header.Get("Link"); want != have {
+			t.Errorf("[%d] expected %s, got %s", i, want, have)
+		}
+		if want, have := test.total, resp.Header.Get("X-Total-Count"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %s, got %s", i, want, have)
+		}
+	}
+}
+
+// fetchAll follows the cursors of CursorPaginatedRT to the end
+func fetchAll(client *http.Client, url string) (items []int, pages int, err error) {
+	cursor := ""
+	for pages < 10 {
+		resp, err := client.Get(url + "?cursor=" + cursor)
+		if err != nil {
+			return nil, pages, err
+		}
+		var page struct {
+			Items      []int   `json:"items"`
+			NextCursor *string `json:"next_cursor"`
+			HasMore    bool    `json:"has_more"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, pages, err
+		}
+		pages++
+		items = append(items, page.Items...)
+		if page.NextCursor == nil {
+			return items, pages, nil
+		}
+		cursor = *page.NextCursor
+	}
+	return nil, pages, fmt.Errorf("too many pages")
+}
+
+func TestCursorPaginatedRT(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+	tests := []struct {
+		rt    http.RoundTripper
+		pages int
+	}{
+		{rt: mockhttp.CursorPaginatedRT(items, 2), pages: 2},
+		{rt: mockhttp.CursorPaginatedRT(items, 3), pages: 2},
+		{rt: mockhttp.CursorPaginatedRT(items, 2, mockhttp.PageEmptyLast()), pages: 3},
+		{rt: mockhttp.CursorPaginatedRT([]int{}, 2), pages: 1},
+	}
+	for i, test := range tests {
+		all, pages, err := fetchAll(mockhttp.NewClient(test.rt), "https://api.example.com/items")
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.pages, pages; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if i < 3 {
+			if want, have := fmt.Sprint(items), fmt.Sprint(all); want != have {
+				t.Errorf("[%d] expected %s, got %s", i, want, have)
+			}
+		}
+	}
+
+	// first page and invalid cursor
+	rt := mockhttp.CursorPaginatedRT(items, 2, mockhttp.PageTotalSkew(-1))
+	for i, test := range []struct {
+		url     string
+		status  int
+		content string
+	}{
+		{url: "https://api.example.com/items", status: http.StatusOK, content: `{"items":[1,2],"next_cursor":"b2Zmc2V0OjI","has_more":true,"total":3}`},
+		{url: "https://api.example.com/items?limit=4", status: http.StatusOK, content: `{"items":[1,2,3,4],"next_cursor":null,"has_more":false,"total":3}`},
+		{url: "https://api.example.com/items?cursor=b2Zmc2V0OjI&limit=9223372036854775807", status: http.StatusOK, content: `{"items":[3,4],"next_cursor":null,"has_more":false,"total":3}`},
+		{url: "https://api.example.com/items?cursor=invalid", status: http.StatusBadRequest, content: `{"title":"Bad Request","status":400,"detail":"invalid cursor"}`},
+	} {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %s, got %s", i, want, have)
+		}
+	}
+}
+
+func TestOffsetPaginatedRT(t *testing.T) {
+	rt := mockhttp.OffsetPaginatedRT([]string{"a", "b", "c", "d", "e"}, 2)
+	tests := []struct {
+		url     string
+		status  int
+		content string
+	}{
+		{url: "https://api.example.com/items", status: http.StatusOK, content: `{"items":["a","b"],"offset":0,"limit":2,"total":5}`},
+		{url: "https://api.example.com/items?offset=3&limit=10", status: http.StatusOK, content: `{"items":["d","e"],"offset":3,"limit":10,"total":5}`},
+		{url: "https://api.example.com/items?offset=5", status: http.StatusOK, content: `{"items":[],"offset":5,"limit":2,"total":5}`},
+		{url: "https://api.example.com/items?offset=1&limit=9223372036854775807", status: http.StatusOK, content: `{"items":["b","c","d","e"],"offset":1,"limit":9223372036854775807,"total":5}`},
+		{url: "https://api.example.com/items?offset=-1", status: http.StatusBadRequest, content: `{"title":"Bad Request","status":400,"detail":"invalid offset"}`},
+		{url: "https://api.example.com/items?limit=0", status: http.StatusBadRequest, content: `{"title":"Bad Request","status":400,"detail":"invalid limit"}`},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %s, got %s", i, want, have)
+		}
+	}
+}
+
+func TestLinkPaginatedRT_invalidItems(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic, got nil")
+		}
+	}()
+	mockhttp.LinkPaginatedRT("not a slice", 10)
+}
+
+func TestLinkPaginatedRT_resource(t *testing.T) {
+	res := mockhttp.NewResourceSimulator("/persons")
+	if err := res.SeedDir("./testdata/persons"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp, err := mockhttp.NewClient(mockhttp.LinkPaginatedRT(res.Items(), 10)).Get("https://api.example.com/persons")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := `[{"cool":true,"id":1,"name":"Elon Musk"}]`, string(content); want != have {
+		t.Errorf("expected %s, got %s", want, have)
+	}
+}
@@ -0,0 +1,108 @@
+package mockhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// connectionHeaders are connection-specific headers that are not
+// allowed in HTTP/2 and HTTP/3 responses.
+var connectionHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// parseProto parses protocol versions like "HTTP/1.1" and "HTTP/2".
+func parseProto(proto string) (major, minor int, ok bool) {
+	switch proto {
+	case "HTTP/2", "HTTP/2.0":
+		return 2, 0, true
+	case "HTTP/3", "HTTP/3.0":
+		return 3, 0, true
+	}
+	major, minor, ok = http.ParseHTTPVersion(proto)
+	return major, minor, ok && major == 1
+}
+
+// ResponseSetProto sets the protocol version of the response, if
+// presents, to proto ("HTTP/1.0", "HTTP/1.1", "HTTP/2.0" or "HTTP/3.0"),
+// and shapes the response according to the protocol:
+//
+//   - HTTP/1.0 has no chunked transfer encoding nor trailers. The
+//     connection is closed after the response unless the response
+//     has "Connection: keep-alive" header and a known length.
+//   - HTTP/1.1 responses of unknown length, or with trailers, use
+//     chunked transfer encoding. The connection is closed if the
+//     response has "Connection: close" header.
+//   - HTTP/2.0 and HTTP/3.0 have no connection-specific headers
+//     (e.g. Connection, Transfer-Encoding) nor pseudo-headers in
+//     the header map, and never close the connection per response.
+//
+// ResponseSetProto panics if proto is not one of the above.
+func ResponseSetProto(proto string) ResponseModifier {
+	major, minor, ok := parseProto(proto)
+	if !ok {
+		panic(fmt.Sprintf("mockhttp: unsupported protocol %#v", proto))
+	}
+	proto = fmt.Sprintf("HTTP/%d.%d", major, minor)
+
+	return func(resp *http.Response, err error) (*http.Response, error) {
+		if resp == nil {
+			return resp, err
+		}
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		resp.Proto, resp.ProtoMajor, resp.ProtoMinor = proto, major, minor
+
+		switch {
+		case major == 1 && minor == 0:
+			resp.TransferEncoding = nil
+			resp.Header.Del("Transfer-Encoding")
+			resp.Trailer = nil
+			resp.Header.Del("Trailer")
+			keepAlive := strings.EqualFold(resp.Header.Get("Connection"), "keep-alive")
+			resp.Close = !keepAlive || resp.ContentLength < 0
+			if resp.Close {
+				resp.Header.Del("Connection")
+			}
+		case major == 1:
+			if resp.ContentLength < 0 || len(resp.Trailer) > 0 {
+				resp.TransferEncoding = []string{"chunked"}
+				resp.ContentLength = -1
+				resp.Header.Del("Content-Length")
+			}
+			resp.Close = strings.EqualFold(resp.Header.Get("Connection"), "close")
+		default:
+			for _, key := range connectionHeaders {
+				resp.Header.Del(key)
+			}
+			for key := range resp.Header {
+				if strings.HasPrefix(key, ":") {
+					delete(resp.Header, key)
+				}
+			}
+			resp.TransferEncoding = nil
+			resp.Close = false
+		}
+		return resp, err
+	}
+}
+
+// ResponseSetTrailer sets the response, if presents, trailer
+// with given key-value pair.
+func ResponseSetTrailer(key, value string) ResponseModifier {
+	return func(resp *http.Response, err error) (*http.Response, error) {
+		if resp != nil {
+			if resp.Trailer == nil {
+				resp.Trailer = make(http.Header)
+			}
+			resp.Trailer.Set(key, value)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,137 @@
+package mockhttp_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestResponseSetProto(t *testing.T) {
+
+	// response of unknown length with connection-specific headers
+	unknownLength := mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: -1,
+			Header: http.Header{
+				"Connection": {"keep-alive"},
+				"Keep-Alive": {"timeout=5"},
+				":status":    {"200"},
+			},
+			Body:    ioutil.NopCloser(strings.NewReader("hello world")),
+			Request: r,
+		}, nil
+	})
+
+	tests := []struct {
+		proto            string
+		rt               http.RoundTripper
+		wantProto        string
+		wantMajor        int
+		wantMinor        int
+		wantClose        bool
+		transferEncoding []string
+		connection       string
+		trailer          string
+	}{
+		{
+			proto:     "HTTP/1.0",
+			rt:        mockhttp.StaticResponseRT("hello world", "text/plain"),
+			wantProto: "HTTP/1.0", wantMajor: 1, wantMinor: 0,
+			wantClose: true,
+		},
+		{
+			proto:     "HTTP/1.0",
+			rt:        mockhttp.UseResponseModifier(mockhttp.ResponseSetHeader("Connection", "keep-alive")).Wrap(mockhttp.StaticResponseRT("hello world", "text/plain")),
+			wantProto: "HTTP/1.0", wantMajor: 1, wantMinor: 0,
+			wantClose:  false,
+			connection: "keep-alive",
+		},
+		{
+			proto:     "HTTP/1.0",
+			rt:        unknownLength,
+			wantProto: "HTTP/1.0", wantMajor: 1, wantMinor: 0,
+			wantClose: true,
+		},
+		{
+			proto:     "HTTP/1.1",
+			rt:        unknownLength,
+			wantProto: "HTTP/1.1", wantMajor: 1, wantMinor: 1,
+			transferEncoding: []string{"chunked"},
+			connection:       "keep-alive",
+			trailer:          "abc",
+		},
+		{
+			proto:     "HTTP/1.1",
+			rt:        mockhttp.UseResponseModifier(mockhttp.ResponseSetHeader("Connection", "close")).Wrap(mockhttp.StaticResponseRT("hello world", "text/plain")),
+			wantProto: "HTTP/1.1", wantMajor: 1, wantMinor: 1,
+			wantClose:        true,
+			transferEncoding: []string{"chunked"},
+			connection:       "close",
+			trailer:          "abc",
+		},
+		{
+			proto:     "HTTP/2",
+			rt:        unknownLength,
+			wantProto: "HTTP/2.0", wantMajor: 2, wantMinor: 0,
+			trailer: "abc",
+		},
+		{
+			proto:     "HTTP/3.0",
+			rt:        unknownLength,
+			wantProto: "HTTP/3.0", wantMajor: 3, wantMinor: 0,
+			trailer: "abc",
+		},
+	}
+
+	for i, test := range tests {
+		client := &http.Client{
+			Transport: mockhttp.UseResponseModifier(
+				mockhttp.ResponseSetTrailer("X-Checksum", "abc"),
+				mockhttp.ResponseSetProto(test.proto),
+			).Wrap(test.rt),
+		}
+		resp, err := client.Get("https://foobar.com/")
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.wantProto, resp.Proto; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if !resp.ProtoAtLeast(test.wantMajor, test.wantMinor) || resp.ProtoAtLeast(test.wantMajor, test.wantMinor+1) {
+			t.Errorf("[%d] unexpected protocol version %d.%d", i, resp.ProtoMajor, resp.ProtoMinor)
+		}
+		if want, have := test.wantClose, resp.Close; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := len(test.transferEncoding), len(resp.TransferEncoding); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, test.transferEncoding, resp.TransferEncoding)
+		}
+		if want, have := test.connection, resp.Header.Get("Connection"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.trailer, resp.Trailer.Get("X-Checksum"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if test.wantMajor >= 2 {
+			for key := range resp.Header {
+				if key == "Keep-Alive" || strings.HasPrefix(key, ":") {
+					t.Errorf("[%d] unexpected header %#v", i, key)
+				}
+			}
+		}
+	}
+}
+
+func TestResponseSetProto_unsupported(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic, got nil")
+		}
+	}()
+	mockhttp.ResponseSetProto("SPDY/3")
+}
@@ -0,0 +1,171 @@
+package mockhttp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+// Proxy is a local HTTP forward proxy that serves every proxied
+// request with an http.RoundTripper, instead of connecting to the
+// destination. It allows requests from spawned binaries, which
+// honor the HTTP_PROXY / HTTPS_PROXY environment variables, to be
+// served by the same mock definitions.
+//
+// HTTPS requests (CONNECT tunnels) are intercepted with certificates
+// issued on the fly by the proxy's own certificate authority. Clients
+// should trust the CA certificate (see CACertPEM) for that to work.
+type Proxy struct {
+	*httptest.Server
+
+	rt    http.RoundTripper
+	certs *hostCerts
+}
+
+// NewProxy starts and returns a new Proxy that serves requests with
+// the http.RoundTripper. The caller should call Close when finished,
+// to shut it down.
+func NewProxy(rt http.RoundTripper) *Proxy {
+	proxy := &Proxy{
+		rt:    rt,
+		certs: newHostCerts("mockhttp proxy CA"),
+	}
+	proxy.Server = httptest.NewServer(proxy)
+	return proxy
+}
+
+// CACert returns the certificate of the proxy's certificate authority
+func (proxy *Proxy) CACert() *x509.Certificate {
+	return proxy.certs.ca.cert
+}
+
+// CACertPEM returns the PEM encoded certificate of the proxy's
+// certificate authority. It may be written to a file for the
+// spawned binaries to trust (e.g. with SSL_CERT_FILE).
+func (proxy *Proxy) CACertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: proxy.certs.ca.cert.Raw})
+}
+
+// Environ returns the environment variables, in the form "key=value",
+// for a spawned binary to use the proxy.
+func (proxy *Proxy) Environ() []string {
+	return []string{
+		"HTTP_PROXY=" + proxy.URL,
+		"HTTPS_PROXY=" + proxy.URL,
+		"http_proxy=" + proxy.URL,
+		"https_proxy=" + proxy.URL,
+		"NO_PROXY=",
+		"no_proxy=",
+	}
+}
+
+// Client returns an http.Client that sends requests through the proxy
+// and trusts the proxy's certificate authority.
+func (proxy *Proxy) Client() *http.Client {
+	proxyURL, _ := url.Parse(proxy.URL)
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyURL(proxyURL),
+			TLSClientConfig: &tls.Config{RootCAs: proxy.certs.ca.certPool()},
+		},
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (proxy *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		proxy.serveConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "mockhttp proxy only serves proxy requests", http.StatusBadRequest)
+		return
+	}
+
+	outreq := r.Clone(r.Context())
+	outreq.RequestURI = ""
+	outreq.Header.Del("Proxy-Connection")
+	outreq.Header.Del("Proxy-Authorization")
+	resp, err := proxy.rt.RoundTrip(outreq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeResponse(w, resp)
+}
+
+// serveConnect intercepts the CONNECT tunnel and serves the requests
+// within with the http.RoundTripper.
+func (proxy *Proxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be hijacked", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+
+	connectHost, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		connectHost = r.Host
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return proxy.certs.get(hello.ServerName), nil
+			}
+			return proxy.certs.get(connectHost), nil
+		},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(tlsConn)
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		req.URL.Scheme = "https"
+		req.URL.Host = r.Host
+		if req.Host != "" {
+			req.URL.Host = req.Host
+		}
+		req = req.WithContext(r.Context())
+
+		body := req.Body
+		resp, err := proxy.rt.RoundTrip(req)
+
+		// drain the unread request body before reading the next request
+		io.Copy(ioutil.Discard, body)
+		body.Close()
+
+		if err != nil {
+			resp = bytesResponse(req, http.StatusBadGateway, "text/plain; charset=utf-8", []byte(err.Error()))
+		}
+		resp.Request = req
+		resp.ProtoMajor, resp.ProtoMinor = 1, 1
+		err = resp.Write(tlsConn)
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+		if err != nil || req.Close || resp.Close {
+			return
+		}
+	}
+}
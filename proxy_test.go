@@ -0,0 +1,173 @@
+package mockhttp_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestProxy(t *testing.T) {
+	mux := mockhttp.NewMuxRoundTripper()
+	mux.Add("api.example.com", mockhttp.StaticResponseRT("api", "text/plain"))
+	mux.Add("https://secure.example.com", mockhttp.StaticResponseRT("secure", "text/plain"))
+	mux.AddFunc("echo.example.com", func(r *http.Request) (*http.Response, error) {
+		content, _ := ioutil.ReadAll(r.Body)
+		return mockhttp.StaticResponseRT(fmt.Sprintf("%s %s %s", r.Method, r.URL, content), "text/plain")(r)
+	})
+	mux.Add("*", mockhttp.TransportErrorRT(fmt.Errorf("no network")))
+
+	proxy := mockhttp.NewProxy(mux)
+	defer proxy.Close()
+	client := proxy.Client()
+
+	tests := []struct {
+		method  string
+		url     string
+		body    string
+		status  int
+		content string
+	}{
+		{method: "GET", url: "http://api.example.com/", status: http.StatusOK, content: "api"},
+		{method: "GET", url: "https://api.example.com/", status: http.StatusOK, content: "api"},
+		{method: "GET", url: "https://secure.example.com/", status: http.StatusOK, content: "secure"},
+		{method: "GET", url: "http://secure.example.com/", status: http.StatusBadGateway, content: "no network\n"},
+		{method: "GET", url: "https://www.foobar.com/", status: http.StatusBadGateway, content: "no network"},
+		{method: "POST", url: "https://echo.example.com:8443/hello?foo=bar", body: "hello", status: http.StatusOK, content: "POST https://echo.example.com:8443/hello?foo=bar hello"},
+		{method: "POST", url: "http://echo.example.com/hello", body: "world", status: http.StatusOK, content: "POST http://echo.example.com/hello world"},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, strings.NewReader(test.body))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestProxy_keepAlive(t *testing.T) {
+	proxy := mockhttp.NewProxy(mockhttp.StaticResponseRT("hello", "text/plain"))
+	defer proxy.Close()
+
+	// open a tunnel to send requests over the same connection
+	proxyURL, _ := url.Parse(proxy.URL)
+	conn, err := net.Dial("tcp", proxyURL.Host)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "CONNECT api.example.com:443 HTTP/1.1\r\nHost: api.example.com:443\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Fatalf("expected %#v, got %#v", want, have)
+	}
+	tlsConn := tls.Client(&bufferedConn{conn, reader}, &tls.Config{ServerName: "api.example.com", InsecureSkipVerify: true})
+	tlsReader := bufio.NewReader(tlsConn)
+
+	// request body is ignored by the RoundTripper
+	tests := []struct {
+		method string
+		body   string
+	}{
+		{method: "POST", body: "ignored request body"},
+		{method: "GET"},
+		{method: "POST", body: "another ignored body"},
+		{method: "GET"},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, "https://api.example.com/", strings.NewReader(test.body))
+		if test.body == "" {
+			req.Body = nil
+		}
+		if err := req.Write(tlsConn); err != nil {
+			t.Fatalf("[%d] unexpected error: %s", i, err)
+		}
+		resp, err := http.ReadResponse(tlsReader, req)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %s", i, err)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want, have := http.StatusOK, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "hello", string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+// bufferedConn is a net.Conn that reads from the buffered reader
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func TestProxy_CACertPEM(t *testing.T) {
+	proxy := mockhttp.NewProxy(mockhttp.StaticResponseRT("hello world", "text/plain"))
+	defer proxy.Close()
+
+	// a client configured only by the information exposed by proxy
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(proxy.CACertPEM()) {
+		t.Errorf("failed to parse CA certificate PEM")
+		return
+	}
+	proxyURL, _ := url.Parse(proxy.URL)
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyURL(proxyURL),
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+	resp, err := client.Get("https://www.google.com/")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := "hello world", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "www.google.com", resp.TLS.PeerCertificates[0].Subject.CommonName; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	found := false
+	for _, env := range proxy.Environ() {
+		if env == "HTTPS_PROXY="+proxy.URL {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected HTTPS_PROXY in %#v", proxy.Environ())
+	}
+}
@@ -0,0 +1,227 @@
+package mockhttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errUnsatisfiableRange is returned by parseRange if none of the
+// ranges overlaps the content.
+var errUnsatisfiableRange = errors.New("unsatisfiable range")
+
+// byteRange is a satisfiable byte range of a content
+type byteRange struct {
+	start, length int64
+}
+
+// contentRange returns the Content-Range header value of the range
+func (br byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", br.start, br.start+br.length-1, size)
+}
+
+// parseRange parses the Range header value (e.g. "bytes=0-499,-500")
+// against a content of the size. Unsatisfiable ranges are dropped. It
+// returns nil if the header is not a valid byte ranges specifier, which
+// should be ignored, or errUnsatisfiableRange if no range is left.
+func parseRange(header string, size int64) ([]byteRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, nil
+	}
+	var ranges []byteRange
+	for _, spec := range strings.Split(header[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		dash := strings.Index(spec, "-")
+		if dash < 0 {
+			return nil, nil
+		}
+		first, last := spec[:dash], spec[dash+1:]
+		if first == "" {
+			// suffix range, e.g. "-500"
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, nil
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			ranges = append(ranges, byteRange{start: size - n, length: n})
+			continue
+		}
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return nil, nil
+		}
+		end := size - 1
+		if last != "" {
+			if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+				return nil, nil
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+		if start >= size {
+			continue
+		}
+		ranges = append(ranges, byteRange{start: start, length: end - start + 1})
+	}
+	if len(ranges) == 0 {
+		return nil, errUnsatisfiableRange
+	}
+	return ranges, nil
+}
+
+// ifRangeMatch reports if the If-Range header value matches the
+// strong etag or the exact modification time of a file.
+func ifRangeMatch(header, etag string, modTime time.Time) bool {
+	if strings.HasPrefix(header, `"`) {
+		return header == etag
+	}
+	if strings.HasPrefix(header, "W/") {
+		return false // weak validator never matches
+	}
+	t, err := http.ParseTime(header)
+	return err == nil && t.Equal(modTime.Truncate(time.Second))
+}
+
+// sectionBody is the body of a single range response
+type sectionBody struct {
+	io.Reader
+	io.Closer
+}
+
+// multipartRanges writes the ranges of content, in multipart/byteranges
+// format, into a buffer. It returns the buffer and the Content-Type of it.
+func multipartRanges(content io.ReaderAt, size int64, contentType string, ranges []byteRange) (*bytes.Buffer, string, error) {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	for _, br := range ranges {
+		header := make(textproto.MIMEHeader)
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		header.Set("Content-Range", br.contentRange(size))
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err = io.Copy(part, io.NewSectionReader(content, br.start, br.length)); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf, "multipart/byteranges; boundary=" + w.Boundary(), nil
+}
+
+// FileInterruptAfter makes FileSystemRT fail every response body longer
+// than n bytes, including the body of partial content, with
+// io.ErrUnexpectedEOF after n bytes is read. It simulates connections
+// that drop mid-transfer.
+func FileInterruptAfter(n int64) FileSystemOption {
+	return func(config *fileSystemConfig) {
+		config.interruptAfter = n
+	}
+}
+
+// interruptedBody is a response body that fails after some bytes
+type interruptedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (ib *interruptedBody) Read(p []byte) (n int, err error) {
+	if ib.remaining <= 0 {
+		// fail only if the body has more to read
+		var b [1]byte
+		if n, err = ib.body.Read(b[:]); n > 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	if int64(len(p)) > ib.remaining {
+		p = p[:ib.remaining]
+	}
+	n, err = ib.body.Read(p)
+	ib.remaining -= int64(n)
+	return
+}
+
+func (ib *interruptedBody) Close() error {
+	return ib.body.Close()
+}
+
+// serveRange shapes the file response into a partial content response
+// according to the Range header of the request. The response is left
+// untouched if the request has no valid Range header, or if its
+// If-Range header does not match the file.
+func serveRange(resp *http.Response, f io.ReadSeeker, etag string, modTime time.Time) error {
+	r := resp.Request
+	header := r.Header.Get("Range")
+	if header == "" || (r.Method != "" && r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return nil
+	}
+	if ifRange := r.Header.Get("If-Range"); ifRange != "" && !ifRangeMatch(ifRange, etag, modTime) {
+		return nil
+	}
+
+	size := resp.ContentLength
+	ranges, err := parseRange(header, size)
+	if err == errUnsatisfiableRange {
+		status := http.StatusRequestedRangeNotSatisfiable
+		statusText := http.StatusText(status)
+		resp.Body.Close()
+		resp.Status, resp.StatusCode = statusText, status
+		resp.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		resp.Header.Set("Content-Type", "text/plain")
+		resp.Header.Set("Content-Length", strconv.Itoa(len(statusText)))
+		resp.ContentLength = int64(len(statusText))
+		resp.Body = ioutil.NopCloser(strings.NewReader(statusText))
+		return nil
+	}
+	if ranges == nil {
+		return nil
+	}
+
+	resp.Status, resp.StatusCode = http.StatusText(http.StatusPartialContent), http.StatusPartialContent
+	if len(ranges) == 1 {
+		br := ranges[0]
+		if _, err := f.Seek(br.start, io.SeekStart); err != nil {
+			return err
+		}
+		resp.Header.Set("Content-Range", br.contentRange(size))
+		resp.Header.Set("Content-Length", strconv.FormatInt(br.length, 10))
+		resp.ContentLength = br.length
+		resp.Body = sectionBody{Reader: io.LimitReader(f, br.length), Closer: resp.Body}
+		return nil
+	}
+
+	readerAt, ok := f.(io.ReaderAt)
+	if !ok {
+		return fmt.Errorf("file does not support multiple ranges")
+	}
+	buf, contentType, err := multipartRanges(readerAt, size, resp.Header.Get("Content-Type"), ranges)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	resp.Header.Set("Content-Type", contentType)
+	resp.Header.Set("Content-Length", strconv.Itoa(buf.Len()))
+	resp.ContentLength = int64(buf.Len())
+	resp.Body = ioutil.NopCloser(buf)
+	return nil
+}
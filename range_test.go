@@ -0,0 +1,197 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestFileSystemRT_range(t *testing.T) {
+	rt := mockhttp.FileSystemRT("./testdata")
+
+	// content of ./testdata/test.txt is "hello world"
+	tests := []struct {
+		rangeHeader  string
+		status       int
+		contentRange string
+		content      string
+	}{
+		{rangeHeader: "bytes=0-4", status: http.StatusPartialContent, contentRange: "bytes 0-4/11", content: "hello"},
+		{rangeHeader: "bytes=6-", status: http.StatusPartialContent, contentRange: "bytes 6-10/11", content: "world"},
+		{rangeHeader: "bytes=-3", status: http.StatusPartialContent, contentRange: "bytes 8-10/11", content: "rld"},
+		{rangeHeader: "bytes=6-100", status: http.StatusPartialContent, contentRange: "bytes 6-10/11", content: "world"},
+		{rangeHeader: "bytes=-100", status: http.StatusPartialContent, contentRange: "bytes 0-10/11", content: "hello world"},
+		{rangeHeader: "bytes=20-30, 6-", status: http.StatusPartialContent, contentRange: "bytes 6-10/11", content: "world"},
+		{rangeHeader: "bytes=11-", status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */11", content: "Requested Range Not Satisfiable"},
+		{rangeHeader: "bytes=5-2", status: http.StatusOK, content: "hello world"},
+		{rangeHeader: "lines=1-2", status: http.StatusOK, content: "hello world"},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+		req.Header.Set("Range", test.rangeHeader)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.contentRange, resp.Header.Get("Content-Range"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := int64(len(content)), resp.ContentLength; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestFileSystemRT_multipleRanges(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+	req.Header.Set("Range", "bytes=0-4, -5")
+	resp, err := mockhttp.FileSystemRT("./testdata").RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusPartialContent, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := "multipart/byteranges", mediaType; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	parts := []struct {
+		contentRange string
+		content      string
+	}{
+		{contentRange: "bytes 0-4/11", content: "hello"},
+		{contentRange: "bytes 6-10/11", content: "world"},
+	}
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for i, want := range parts {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %s", i, err)
+		}
+		if have := part.Header.Get("Content-Range"); want.contentRange != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want.contentRange, have)
+		}
+		if have := part.Header.Get("Content-Type"); have != "text/plain; charset=utf-8" {
+			t.Errorf("[%d] expected %#v, got %#v", i, "text/plain; charset=utf-8", have)
+		}
+		content, _ := ioutil.ReadAll(part)
+		if have := string(content); want.content != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want.content, have)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %#v", err)
+	}
+}
+
+func TestFileSystemRT_ifRange(t *testing.T) {
+	rt := mockhttp.FileSystemRT("./testdata")
+	req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := "bytes", resp.Header.Get("Accept-Ranges"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	tests := []struct {
+		ifRange string
+		status  int
+	}{
+		{ifRange: resp.Header.Get("ETag"), status: http.StatusPartialContent},
+		{ifRange: resp.Header.Get("Last-Modified"), status: http.StatusPartialContent},
+		{ifRange: `"outdated"`, status: http.StatusOK},
+		{ifRange: "W/" + resp.Header.Get("ETag"), status: http.StatusOK},
+		{ifRange: "Thu, 01 Jan 1970 00:00:00 GMT", status: http.StatusOK},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+		req.Header.Set("Range", "bytes=0-4")
+		req.Header.Set("If-Range", test.ifRange)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestFileInterruptAfter(t *testing.T) {
+	rt := mockhttp.FileSystemRT("./testdata", mockhttp.FileInterruptAfter(5))
+
+	// resume the download until it completes
+	var content []byte
+	for i := 0; i < 5 && len(content) < 11; i++ {
+		req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+		if len(content) > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", len(content)))
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %s", i, err)
+		}
+		partial, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		content = append(content, partial...)
+		if len(content) < 11 && err != io.ErrUnexpectedEOF {
+			t.Errorf("[%d] expected io.ErrUnexpectedEOF, got %#v", i, err)
+		}
+	}
+	if want, have := "hello world", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestFileInterruptAfter_boundary(t *testing.T) {
+	tests := []struct {
+		after   int64
+		content string
+		err     error
+	}{
+		{after: 10, content: "hello worl", err: io.ErrUnexpectedEOF},
+		{after: 11, content: "hello world"},
+		{after: 12, content: "hello world"},
+	}
+
+	for i, test := range tests {
+		rt := mockhttp.FileSystemRT("./testdata", mockhttp.FileInterruptAfter(test.after))
+		req, _ := http.NewRequest("GET", "https://foobar.com/test.txt", nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		content, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want, have := test.err, err; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
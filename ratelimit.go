@@ -0,0 +1,241 @@
+package mockhttp
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitAlgorithm is the algorithm of RateLimiter
+type RateLimitAlgorithm int
+
+// Supported RateLimitAlgorithm
+const (
+	// RateLimitFixedWindow allows Limit requests in each Window,
+	// which starts from the first request after the last window.
+	RateLimitFixedWindow RateLimitAlgorithm = iota
+
+	// RateLimitTokenBucket allows bursts of Limit requests, with
+	// tokens refilled at the rate of Limit per Window.
+	RateLimitTokenBucket
+)
+
+// RateLimitHeaders is the kind of rate limit headers responded by
+// RateLimiter.
+type RateLimitHeaders int
+
+// Supported RateLimitHeaders
+const (
+	// RateLimitHeadersX responds X-RateLimit-Limit, X-RateLimit-Remaining
+	// and X-RateLimit-Reset (in Unix time) headers, like the GitHub API.
+	RateLimitHeadersX RateLimitHeaders = iota
+
+	// RateLimitHeadersIETF responds RateLimit-Policy, RateLimit-Limit,
+	// RateLimit-Remaining and RateLimit-Reset (in seconds) headers of
+	// the IETF RateLimit header fields draft.
+	RateLimitHeadersIETF
+
+	// RateLimitHeadersBoth responds both kinds of headers.
+	RateLimitHeadersBoth
+)
+
+// RateLimitConfig configures RateLimiter
+type RateLimitConfig struct {
+	// Limit is the number of requests allowed in a Window. Required.
+	Limit int
+
+	// Window is the duration of the limit. Required.
+	Window time.Duration
+
+	// Algorithm of the limit. Default RateLimitFixedWindow.
+	Algorithm RateLimitAlgorithm
+
+	// Key returns the key of the request. Requests of different keys
+	// are limited separately. Default RateLimitByHost.
+	Key func(r *http.Request) string
+
+	// Headers is the kind of rate limit headers. Default
+	// RateLimitHeadersX.
+	Headers RateLimitHeaders
+
+	// Now returns the current time. Default time.Now. It may be
+	// replaced to control the time in tests (see ManualClock).
+	Now func() time.Time
+}
+
+// RateLimitByHost limits requests by the host of request URL
+func RateLimitByHost(r *http.Request) string {
+	return r.URL.Host
+}
+
+// RateLimitByRoute limits requests by the method, host and path of
+// request URL.
+func RateLimitByRoute(r *http.Request) string {
+	return r.Method + " " + r.URL.Host + r.URL.Path
+}
+
+// RateLimitByHeader returns a key function that limits requests by
+// the value of the header (e.g. "X-API-Key"). Requests without the
+// header share the same limit.
+func RateLimitByHeader(key string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(key)
+	}
+}
+
+// rateState is the state of the limit of a key
+type rateState struct {
+	start  time.Time // start of fixed window
+	count  int       // requests in fixed window
+	tokens float64   // tokens in bucket
+	last   time.Time // last refill of bucket
+}
+
+// RateLimiter is a Middleware that simulates the rate limit of a
+// server. Requests over the limit get a 429 Too Many Requests
+// response with Retry-After header, instead of being passed to the
+// inner http.RoundTripper. All responses have the rate limit headers.
+type RateLimiter struct {
+	config RateLimitConfig
+	lock   sync.Mutex
+	states map[string]*rateState
+}
+
+// NewRateLimiter returns a new RateLimiter with the config. It
+// panics if config.Limit or config.Window is not positive.
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	if config.Limit < 1 {
+		panic(fmt.Sprintf("mockhttp: invalid rate limit %d", config.Limit))
+	}
+	if config.Window <= 0 {
+		panic(fmt.Sprintf("mockhttp: invalid rate limit window %s", config.Window))
+	}
+	if config.Key == nil {
+		config.Key = RateLimitByHost
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &RateLimiter{
+		config: config,
+		states: make(map[string]*rateState),
+	}
+}
+
+// take a request from the limit of the key. It returns if the request
+// is allowed, the remaining requests, the duration until the limit is
+// fully reset, and the duration until next request is allowed.
+func (rl *RateLimiter) take(key string) (allowed bool, remaining int, reset, retryAfter time.Duration) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	now := rl.config.Now()
+	limit, window := rl.config.Limit, rl.config.Window
+	state, found := rl.states[key]
+	if !found {
+		state = &rateState{start: now, tokens: float64(limit), last: now}
+		rl.states[key] = state
+	}
+
+	if rl.config.Algorithm == RateLimitTokenBucket {
+		rate := float64(limit) / float64(window) // tokens per nanosecond
+		state.tokens = math.Min(float64(limit), state.tokens+float64(now.Sub(state.last))*rate)
+		state.last = now
+		if allowed = state.tokens >= 1; allowed {
+			state.tokens--
+		} else {
+			retryAfter = time.Duration((1 - state.tokens) / rate)
+		}
+		remaining = int(state.tokens)
+		reset = time.Duration((float64(limit) - state.tokens) / rate)
+		return
+	}
+
+	if !now.Before(state.start.Add(window)) {
+		state.start, state.count = now, 0
+	}
+	reset = state.start.Add(window).Sub(now)
+	if allowed = state.count < limit; allowed {
+		state.count++
+	} else {
+		retryAfter = reset
+	}
+	remaining = limit - state.count
+	return
+}
+
+// Reset clears the limits of all keys.
+func (rl *RateLimiter) Reset() {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	rl.states = make(map[string]*rateState)
+}
+
+// seconds rounds the duration up to seconds
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// Wrap implements Middleware
+func (rl *RateLimiter) Wrap(inner http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (resp *http.Response, err error) {
+		allowed, remaining, reset, retryAfter := rl.take(rl.config.Key(r))
+		if allowed {
+			resp, err = inner.RoundTrip(r)
+		} else {
+			resp, err = ServerErrorRT(http.StatusTooManyRequests)(r)
+			resp.Header.Set("Retry-After", strconv.FormatInt(seconds(retryAfter), 10))
+		}
+		if resp == nil {
+			return
+		}
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+
+		limit := strconv.Itoa(rl.config.Limit)
+		if rl.config.Headers != RateLimitHeadersIETF {
+			resetAt := rl.config.Now().Add(reset + time.Second - time.Nanosecond) // round up
+			resp.Header.Set("X-RateLimit-Limit", limit)
+			resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+		}
+		if rl.config.Headers != RateLimitHeadersX {
+			resp.Header.Set("RateLimit-Policy", fmt.Sprintf("%s;w=%d", limit, seconds(rl.config.Window)))
+			resp.Header.Set("RateLimit-Limit", limit)
+			resp.Header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			resp.Header.Set("RateLimit-Reset", strconv.FormatInt(seconds(reset), 10))
+		}
+		return
+	})
+}
+
+// ManualClock is a clock that only moves when advanced. Its Now
+// method may be used as the Now of RateLimitConfig to test how
+// clients back off and recover without waiting.
+type ManualClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+// NewManualClock returns a new ManualClock at the time
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
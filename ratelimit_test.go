@@ -0,0 +1,186 @@
+package mockhttp_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestRateLimiter_fixedWindow(t *testing.T) {
+	clock := mockhttp.NewManualClock(time.Unix(1600000000, 0))
+	rl := mockhttp.NewRateLimiter(mockhttp.RateLimitConfig{
+		Limit:  2,
+		Window: time.Minute,
+		Now:    clock.Now,
+	})
+	client := mockhttp.NewClient(mockhttp.StaticResponseRT("hello world", "text/plain"),
+		mockhttp.ClientMiddleware(rl))
+
+	tests := []struct {
+		advance    time.Duration
+		url        string
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{url: "https://api.example.com/", status: http.StatusOK, remaining: "1", reset: "1600000060"},
+		{advance: 10 * time.Second, url: "https://api.example.com/", status: http.StatusOK, remaining: "0", reset: "1600000060"},
+		{advance: 10 * time.Second, url: "https://api.example.com/", status: http.StatusTooManyRequests, remaining: "0", reset: "1600000060", retryAfter: "40"},
+		{url: "https://other.example.com/", status: http.StatusOK, remaining: "1", reset: "1600000080"},
+		{advance: 39500 * time.Millisecond, url: "https://api.example.com/", status: http.StatusTooManyRequests, remaining: "0", reset: "1600000060", retryAfter: "1"},
+		{advance: 500 * time.Millisecond, url: "https://api.example.com/", status: http.StatusOK, remaining: "1", reset: "1600000120"},
+	}
+
+	for i, test := range tests {
+		clock.Advance(test.advance)
+		resp, err := client.Get(test.url)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "2", resp.Header.Get("X-RateLimit-Limit"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.remaining, resp.Header.Get("X-RateLimit-Remaining"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.reset, resp.Header.Get("X-RateLimit-Reset"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.retryAfter, resp.Header.Get("Retry-After"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "", resp.Header.Get("RateLimit-Limit"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestRateLimiter_tokenBucket(t *testing.T) {
+	clock := mockhttp.NewManualClock(time.Unix(1600000000, 0))
+	rl := mockhttp.NewRateLimiter(mockhttp.RateLimitConfig{
+		Limit:     3,
+		Window:    3 * time.Second, // 1 token per second
+		Algorithm: mockhttp.RateLimitTokenBucket,
+		Key:       mockhttp.RateLimitByHeader("X-API-Key"),
+		Headers:   mockhttp.RateLimitHeadersIETF,
+		Now:       clock.Now,
+	})
+	rt := rl.Wrap(mockhttp.StaticResponseRT("hello world", "text/plain"))
+
+	tests := []struct {
+		advance    time.Duration
+		apiKey     string
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{apiKey: "alice", status: http.StatusOK, remaining: "2", reset: "1"},
+		{apiKey: "alice", status: http.StatusOK, remaining: "1", reset: "2"},
+		{apiKey: "alice", status: http.StatusOK, remaining: "0", reset: "3"},
+		{apiKey: "alice", status: http.StatusTooManyRequests, remaining: "0", reset: "3", retryAfter: "1"},
+		{apiKey: "bob", status: http.StatusOK, remaining: "2", reset: "1"},
+		{advance: 500 * time.Millisecond, apiKey: "alice", status: http.StatusTooManyRequests, remaining: "0", reset: "3", retryAfter: "1"},
+		{advance: 500 * time.Millisecond, apiKey: "alice", status: http.StatusOK, remaining: "0", reset: "3"},
+		{advance: 10 * time.Second, apiKey: "alice", status: http.StatusOK, remaining: "2", reset: "1"},
+	}
+
+	for i, test := range tests {
+		clock.Advance(test.advance)
+		req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+		req.Header.Set("X-API-Key", test.apiKey)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "3;w=3", resp.Header.Get("RateLimit-Policy"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.remaining, resp.Header.Get("RateLimit-Remaining"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.reset, resp.Header.Get("RateLimit-Reset"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.retryAfter, resp.Header.Get("Retry-After"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := "", resp.Header.Get("X-RateLimit-Limit"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
+
+func TestRateLimiter_route(t *testing.T) {
+	rl := mockhttp.NewRateLimiter(mockhttp.RateLimitConfig{
+		Limit:   1,
+		Window:  time.Hour,
+		Key:     mockhttp.RateLimitByRoute,
+		Headers: mockhttp.RateLimitHeadersBoth,
+	})
+	rt := rl.Wrap(mockhttp.StaticResponseRT("hello world", "text/plain"))
+
+	tests := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{method: "GET", url: "https://api.example.com/a", status: http.StatusOK},
+		{method: "GET", url: "https://api.example.com/b", status: http.StatusOK},
+		{method: "POST", url: "https://api.example.com/a", status: http.StatusOK},
+		{method: "GET", url: "https://api.example.com/a", status: http.StatusTooManyRequests},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if resp.Header.Get("X-RateLimit-Limit") == "" || resp.Header.Get("RateLimit-Limit") == "" {
+			t.Errorf("[%d] expected both kinds of headers, got %#v", i, resp.Header)
+		}
+	}
+
+	// reset the limits
+	rl.Reset()
+	req, _ := http.NewRequest("GET", "https://api.example.com/a", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestNewRateLimiter_invalid(t *testing.T) {
+	tests := []mockhttp.RateLimitConfig{
+		{Limit: 0, Window: time.Second},
+		{Limit: 1, Window: 0},
+	}
+	for i, config := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("[%d] expected panic, got nil", i)
+				}
+			}()
+			mockhttp.NewRateLimiter(config)
+		}()
+	}
+}
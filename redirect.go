@@ -0,0 +1,117 @@
+package mockhttp
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// redirectResponse builds a redirect response of the given status
+// to the location.
+func redirectResponse(r *http.Request, status int, location string) *http.Response {
+	resp := bytesResponse(r, status, "text/html; charset=utf-8",
+		[]byte(fmt.Sprintf("<a href=\"%s\">%s</a>.\n",
+			html.EscapeString(location), http.StatusText(status))))
+	resp.Header.Set("Location", location)
+	return resp
+}
+
+// checkRedirectStatus panics if status is not a redirect status
+// code supported by http.Client.
+func checkRedirectStatus(status int) {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return
+	}
+	panic(fmt.Sprintf("mockhttp: unsupported redirect status %d", status))
+}
+
+// RedirectRT returns an http.RoundTripper that always redirects
+// with the given status code (301, 302, 303, 307 or 308) to
+// the location. The location may be relative to the request URL,
+// or an absolute URL of another host (e.g. to be routed by
+// MuxRoundTripper).
+//
+// RedirectRT panics if status is not one of the supported status.
+func RedirectRT(status int, location string) RoundTripperFunc {
+	checkRedirectStatus(status)
+	return func(r *http.Request) (*http.Response, error) {
+		return redirectResponse(r, status, location), nil
+	}
+}
+
+// RedirectHostRT returns an http.RoundTripper that redirects with
+// the given status code to the same URL on another host, with the
+// same path and query. The scheme of the new URL is the same as the
+// request unless host is prefixed with a scheme (e.g. "https://").
+//
+// RedirectHostRT panics if status is not one of the supported status.
+func RedirectHostRT(status int, host string) RoundTripperFunc {
+	checkRedirectStatus(status)
+	scheme := ""
+	if i := strings.Index(host, "://"); i != -1 {
+		scheme, host = host[:i], host[i+3:]
+	}
+	return func(r *http.Request) (*http.Response, error) {
+		location := *r.URL
+		location.Host = host
+		if scheme != "" {
+			location.Scheme = scheme
+		}
+		return redirectResponse(r, status, location.String()), nil
+	}
+}
+
+// RedirectChainQuery is the URL query key used by RedirectChainRT
+// and RedirectLoopRT to keep track of the redirect hops.
+const RedirectChainQuery = "mockhttp-redirect"
+
+// RedirectChainRT returns an http.RoundTripper that redirects the
+// request, with the given status code, for the given number of hops
+// before passing the request to final. Each hop redirects to the same
+// URL with the hop count in the RedirectChainQuery query value. The
+// query value is removed before passing the request to final.
+//
+// RedirectChainRT panics if status is not one of the supported status.
+func RedirectChainRT(status, hops int, final http.RoundTripper) RoundTripperFunc {
+	checkRedirectStatus(status)
+	return func(r *http.Request) (*http.Response, error) {
+		query := r.URL.Query()
+		hop, _ := strconv.Atoi(query.Get(RedirectChainQuery))
+		if hop < hops {
+			location := *r.URL
+			query.Set(RedirectChainQuery, strconv.Itoa(hop+1))
+			location.RawQuery = query.Encode()
+			return redirectResponse(r, status, location.String()), nil
+		}
+
+		// pass to final without the hop count
+		query.Del(RedirectChainQuery)
+		r2 := r.Clone(r.Context())
+		r2.URL.RawQuery = query.Encode()
+		return final.RoundTrip(r2)
+	}
+}
+
+// RedirectLoopRT returns an http.RoundTripper that redirects, with
+// the given status code, between 2 URLs forever. The URLs differ
+// only by the RedirectChainQuery query value.
+//
+// RedirectLoopRT panics if status is not one of the supported status.
+func RedirectLoopRT(status int) RoundTripperFunc {
+	checkRedirectStatus(status)
+	return func(r *http.Request) (*http.Response, error) {
+		location := *r.URL
+		query := location.Query()
+		if query.Get(RedirectChainQuery) == "loop" {
+			query.Del(RedirectChainQuery)
+		} else {
+			query.Set(RedirectChainQuery, "loop")
+		}
+		location.RawQuery = query.Encode()
+		return redirectResponse(r, status, location.String()), nil
+	}
+}
@@ -0,0 +1,174 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestRedirectRT(t *testing.T) {
+	tests := []struct {
+		status int
+		method string
+		final  string // expected method of the final request
+	}{
+		{status: http.StatusMovedPermanently, method: "GET", final: "GET"},
+		{status: http.StatusFound, method: "POST", final: "GET"},
+		{status: http.StatusSeeOther, method: "POST", final: "GET"},
+		{status: http.StatusTemporaryRedirect, method: "POST", final: "POST"},
+		{status: http.StatusPermanentRedirect, method: "PUT", final: "PUT"},
+	}
+
+	for i, test := range tests {
+		var method, body string
+		mux := mockhttp.NewRequestMux()
+		mux.Add(mockhttp.MatchPath("/old"), mockhttp.RedirectRT(test.status, "/new"))
+		mux.AddFunc(mockhttp.MatchPath("/new"), func(r *http.Request) (*http.Response, error) {
+			method = r.Method
+			if r.Body != nil {
+				content, _ := ioutil.ReadAll(r.Body)
+				body = string(content)
+			}
+			return mockhttp.StaticResponseRT("new content", "text/plain")(r)
+		})
+
+		client := &http.Client{Transport: mux}
+		req, _ := http.NewRequest(test.method, "https://foobar.com/old", strings.NewReader("hello"))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := "new content", string(content); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.final, method; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if test.final != "GET" {
+			if want, have := "hello", body; want != have {
+				t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+			}
+		}
+	}
+}
+
+func TestRedirectRT_unsupported(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic, got nil")
+		}
+	}()
+	mockhttp.RedirectRT(http.StatusOK, "/new")
+}
+
+func TestRedirectHostRT(t *testing.T) {
+	var authorization, url string
+	mock := mockhttp.NewMuxRoundTripper()
+	mock.Add("api.foobar.com", mockhttp.RedirectHostRT(http.StatusFound, "http://cdn.foobar.com"))
+	mock.AddFunc("cdn.foobar.com", func(r *http.Request) (*http.Response, error) {
+		authorization = r.Header.Get("Authorization")
+		url = r.URL.String()
+		return mockhttp.StaticResponseRT("hello world", "text/plain")(r)
+	})
+
+	req, _ := http.NewRequest("GET", "https://api.foobar.com/files/1?size=small", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := mock.NewClient().Do(req)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := "hello world", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "http://cdn.foobar.com/files/1?size=small", url; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// http.Client strips sensitive headers on cross-host redirect
+	if want, have := "", authorization; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestRedirectChainRT(t *testing.T) {
+	var hops int
+	var url string
+	final := mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		url = r.URL.String()
+		return mockhttp.StaticResponseRT("final", "text/plain")(r)
+	})
+	client := &http.Client{
+		Transport: mockhttp.RedirectChainRT(http.StatusFound, 5, final),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			hops = len(via)
+			return nil
+		},
+	}
+
+	resp, err := client.Get("https://foobar.com/start?foo=bar")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := "final", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 5, hops; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "https://foobar.com/start?foo=bar", url; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// exceeding the default policy of http.Client
+	client = &http.Client{
+		Transport: mockhttp.RedirectChainRT(http.StatusFound, 11, final),
+	}
+	if _, err = client.Get("https://foobar.com/start"); err == nil {
+		t.Errorf("expected error, got nil")
+	} else if !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestRedirectLoopRT(t *testing.T) {
+	var urls []string
+	client := &http.Client{
+		Transport: mockhttp.RedirectLoopRT(http.StatusTemporaryRedirect),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			urls = append(urls, req.URL.String())
+			if len(via) >= 4 {
+				return fmt.Errorf("redirect loop")
+			}
+			return nil
+		},
+	}
+	if _, err := client.Get("https://foobar.com/loop"); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+
+	expected := []string{
+		"https://foobar.com/loop?mockhttp-redirect=loop",
+		"https://foobar.com/loop",
+		"https://foobar.com/loop?mockhttp-redirect=loop",
+		"https://foobar.com/loop",
+	}
+	if want, have := len(expected), len(urls); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+		return
+	}
+	for i := range expected {
+		if want, have := expected[i], urls[i]; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
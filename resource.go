@@ -0,0 +1,411 @@
+package mockhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ResourceSimulator simulates a CRUD JSON resource collection of a
+// REST API. It is an http.RoundTripper that remembers writes:
+//
+//	GET    {Path}        lists the items, in the order of id
+//	GET    {Path}/{id}   gets the item
+//	POST   {Path}        creates an item, assigning the next numeric
+//	                     id if the item has none
+//	PUT    {Path}/{id}   creates or replaces the item
+//	PATCH  {Path}/{id}   updates the item with JSON merge patch
+//	                     (RFC 7396)
+//	DELETE {Path}/{id}   deletes the item
+//
+// The list may be paginated with "page" (starts from 1) and
+// "per_page" query parameters. The total number of items is given
+// in the X-Total-Count header.
+//
+// Errors are responded as problem details (see Problem). Requests
+// of other paths are answered with 404 (Not Found).
+type ResourceSimulator struct {
+	// Path is the path of the collection (e.g. "/persons").
+	Path string
+
+	// IDField is the name of the id field of items. Default "id".
+	IDField string
+
+	lock  sync.Mutex
+	items map[string]map[string]interface{}
+}
+
+// NewResourceSimulator returns a new, empty, ResourceSimulator of
+// the collection path, with "id" as IDField. The zero value of
+// ResourceSimulator with Path set is also ready to use.
+func NewResourceSimulator(path string) *ResourceSimulator {
+	return &ResourceSimulator{
+		Path:    path,
+		IDField: "id",
+		items:   make(map[string]map[string]interface{}),
+	}
+}
+
+// idField returns the name of the id field of items
+func (res *ResourceSimulator) idField() string {
+	if res.IDField == "" {
+		return "id"
+	}
+	return res.IDField
+}
+
+// setItem stores the item of id. The caller should hold the lock.
+func (res *ResourceSimulator) setItem(id string, item map[string]interface{}) {
+	if res.items == nil {
+		res.items = make(map[string]map[string]interface{})
+	}
+	res.items[id] = item
+}
+
+// decodeItem decodes the JSON object with numbers kept as json.Number
+func decodeItem(content []byte) (item map[string]interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err = decoder.Decode(&item); err == nil && item == nil {
+		err = fmt.Errorf("item is not a JSON object")
+	}
+	return
+}
+
+// copyItem returns a deep copy of the item
+func copyItem(item map[string]interface{}) map[string]interface{} {
+	content, _ := json.Marshal(item)
+	copied, _ := decodeItem(content)
+	return copied
+}
+
+// itemID returns the id of the item as string, if any
+func (res *ResourceSimulator) itemID(item map[string]interface{}) (string, bool) {
+	switch id := item[res.idField()].(type) {
+	case string:
+		return id, id != ""
+	case json.Number:
+		return id.String(), true
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(id), true
+	}
+	return "", false
+}
+
+// Seed adds the items to the collection. Items may be any value
+// marshaled into JSON object with id.
+func (res *ResourceSimulator) Seed(items ...interface{}) error {
+	for _, v := range items {
+		content, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("error marshaling item: %s", err)
+		}
+		item, err := decodeItem(content)
+		if err != nil {
+			return fmt.Errorf("error decoding item: %s", err)
+		}
+		id, ok := res.itemID(item)
+		if !ok {
+			return fmt.Errorf("item has no %s", res.idField())
+		}
+		res.lock.Lock()
+		res.setItem(id, item)
+		res.lock.Unlock()
+	}
+	return nil
+}
+
+// SeedFS adds the items of every JSON file (*.json) in the directory
+// of fsys to the collection. Items without id have the name of file
+// (e.g. "1" for "1.json") as id.
+func (res *ResourceSimulator) SeedFS(fsys fs.FS, dir string) error {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		item, err := decodeItem(content)
+		if err != nil {
+			return fmt.Errorf("error decoding %s: %s", name, err)
+		}
+		id, ok := res.itemID(item)
+		if !ok {
+			id = strings.TrimSuffix(path.Base(name), ".json")
+			item[res.idField()] = id
+			if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+				item[res.idField()] = json.Number(id)
+			}
+		}
+		res.lock.Lock()
+		res.setItem(id, item)
+		res.lock.Unlock()
+	}
+	return nil
+}
+
+// SeedDir is like SeedFS but reads the JSON files in the directory
+// of the local file system (e.g. "./testdata/persons").
+func (res *ResourceSimulator) SeedDir(dir string) error {
+	return res.SeedFS(os.DirFS(dir), ".")
+}
+
+// sortedIDs returns the ids of items, numeric ids sorted by number
+// before others sorted by string.
+func (res *ResourceSimulator) sortedIDs() []string {
+	ids := make([]string, 0, len(res.items))
+	for id := range res.items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.ParseInt(ids[i], 10, 64)
+		b, errB := strconv.ParseInt(ids[j], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// nextID returns the next numeric id
+func (res *ResourceSimulator) nextID() string {
+	var max int64
+	for id := range res.items {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > max {
+			max = n
+		}
+	}
+	return strconv.FormatInt(max+1, 10)
+}
+
+// Items returns copies of all items, in the order of id.
+func (res *ResourceSimulator) Items() []map[string]interface{} {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	items := make([]map[string]interface{}, 0, len(res.items))
+	for _, id := range res.sortedIDs() {
+		items = append(items, copyItem(res.items[id]))
+	}
+	return items
+}
+
+// Item returns a copy of the item of the id, if exists.
+func (res *ResourceSimulator) Item(id string) (item map[string]interface{}, found bool) {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	if item, found = res.items[id]; found {
+		item = copyItem(item)
+	}
+	return
+}
+
+// Len returns the number of items.
+func (res *ResourceSimulator) Len() int {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	return len(res.items)
+}
+
+// problemResponse returns the problem details response of the status
+func problemResponse(r *http.Request, status int, detail string) *http.Response {
+	content, _ := json.Marshal(Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+	return bytesResponse(r, status, "application/problem+json", content)
+}
+
+// itemResponse returns the JSON response of the item
+func itemResponse(r *http.Request, status int, item interface{}) *http.Response {
+	content, _ := json.Marshal(item)
+	return bytesResponse(r, status, "application/json", content)
+}
+
+// RoundTrip implements http.RoundTripper
+func (res *ResourceSimulator) RoundTrip(r *http.Request) (*http.Response, error) {
+	collection := "/" + strings.Trim(res.Path, "/")
+	reqPath := strings.TrimSuffix(r.URL.Path, "/")
+	if reqPath == collection {
+		switch r.Method {
+		case "", http.MethodGet:
+			return res.list(r), nil
+		case http.MethodPost:
+			return res.create(r)
+		}
+		resp := problemResponse(r, http.StatusMethodNotAllowed, "")
+		resp.Header.Set("Allow", "GET, POST")
+		return resp, nil
+	}
+
+	id := strings.TrimPrefix(reqPath, collection+"/")
+	if id == reqPath || id == "" || strings.Contains(id, "/") {
+		return problemResponse(r, http.StatusNotFound, ""), nil
+	}
+	switch r.Method {
+	case "", http.MethodGet:
+		if item, found := res.Item(id); found {
+			return itemResponse(r, http.StatusOK, item), nil
+		}
+		return problemResponse(r, http.StatusNotFound, fmt.Sprintf("%s %s not found", res.idField(), id)), nil
+	case http.MethodPut, http.MethodPatch:
+		return res.update(r, id)
+	case http.MethodDelete:
+		res.lock.Lock()
+		_, found := res.items[id]
+		delete(res.items, id)
+		res.lock.Unlock()
+		if !found {
+			return problemResponse(r, http.StatusNotFound, fmt.Sprintf("%s %s not found", res.idField(), id)), nil
+		}
+		return emptyResponse(r, http.StatusNoContent, nil), nil
+	}
+	resp := problemResponse(r, http.StatusMethodNotAllowed, "")
+	resp.Header.Set("Allow", "GET, PUT, PATCH, DELETE")
+	return resp, nil
+}
+
+// list responds the items, paginated if requested
+func (res *ResourceSimulator) list(r *http.Request) *http.Response {
+	items := res.Items()
+	total := len(items)
+
+	query := r.URL.Query()
+	if query.Get("page") != "" || query.Get("per_page") != "" {
+		page, err := strconv.Atoi(query.Get("page"))
+		if query.Get("page") == "" {
+			page, err = 1, nil
+		}
+		if err != nil || page < 1 {
+			return problemResponse(r, http.StatusBadRequest, "invalid page")
+		}
+		perPage, err := strconv.Atoi(query.Get("per_page"))
+		if query.Get("per_page") == "" {
+			perPage, err = 20, nil
+		}
+		if err != nil || perPage < 1 {
+			return problemResponse(r, http.StatusBadRequest, "invalid per_page")
+		}
+		start, end := total, total // pages too far to count are empty
+		if page-1 < total/perPage+1 {
+			start = (page - 1) * perPage
+		}
+		if start > total {
+			start = total
+		}
+		if perPage < total-start {
+			end = start + perPage
+		}
+		items = items[start:end]
+	}
+
+	resp := itemResponse(r, http.StatusOK, items)
+	resp.Header.Set("X-Total-Count", strconv.Itoa(total))
+	return resp
+}
+
+// readItem reads the JSON object in the request body
+func readItem(r *http.Request) (map[string]interface{}, error) {
+	if r.Body == nil {
+		return nil, fmt.Errorf("missing request body")
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r.Body); err != nil {
+		return nil, err
+	}
+	return decodeItem(buf.Bytes())
+}
+
+// create the item in the request body
+func (res *ResourceSimulator) create(r *http.Request) (*http.Response, error) {
+	item, err := readItem(r)
+	if err != nil {
+		return problemResponse(r, http.StatusBadRequest, err.Error()), nil
+	}
+
+	res.lock.Lock()
+	id, ok := res.itemID(item)
+	if !ok {
+		id = res.nextID()
+		item[res.idField()] = json.Number(id)
+	} else if _, found := res.items[id]; found {
+		res.lock.Unlock()
+		return problemResponse(r, http.StatusConflict, fmt.Sprintf("%s %s already exists", res.idField(), id)), nil
+	}
+	res.setItem(id, item)
+	item = copyItem(item)
+	res.lock.Unlock()
+
+	resp := itemResponse(r, http.StatusCreated, item)
+	resp.Header.Set("Location", "/"+strings.Trim(res.Path, "/")+"/"+id)
+	return resp, nil
+}
+
+// update the item of id with the request body
+func (res *ResourceSimulator) update(r *http.Request, id string) (*http.Response, error) {
+	patch, err := readItem(r)
+	if err != nil {
+		return problemResponse(r, http.StatusBadRequest, err.Error()), nil
+	}
+
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	item, found := res.items[id]
+	status := http.StatusOK
+	switch {
+	case r.Method == http.MethodPatch && !found:
+		return problemResponse(r, http.StatusNotFound, fmt.Sprintf("%s %s not found", res.idField(), id)), nil
+	case r.Method == http.MethodPatch:
+		item = mergePatch(copyItem(item), patch).(map[string]interface{})
+	case !found:
+		status = http.StatusCreated
+		fallthrough
+	default:
+		item = patch
+	}
+
+	// the id of the item is always the id in path
+	item[res.idField()] = json.Number(id)
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		item[res.idField()] = id
+	}
+	res.setItem(id, item)
+	return itemResponse(r, status, item), nil
+}
+
+// mergePatch applies the JSON merge patch (RFC 7396) to the target
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,154 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestResourceSimulator(t *testing.T) {
+	res := mockhttp.NewResourceSimulator("/persons")
+	if err := res.SeedDir("./testdata/persons"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := res.Seed(map[string]interface{}{"id": 5, "name": "Ada Lovelace"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	client := mockhttp.NewClient(res)
+
+	tests := []struct {
+		method   string
+		path     string
+		body     string
+		status   int
+		location string
+		content  string
+	}{
+		{method: "GET", path: "/persons", status: http.StatusOK, content: `[{"cool":true,"id":1,"name":"Elon Musk"},{"id":5,"name":"Ada Lovelace"}]`},
+		{method: "GET", path: "/persons/1", status: http.StatusOK, content: `{"cool":true,"id":1,"name":"Elon Musk"}`},
+		{method: "GET", path: "/persons/2", status: http.StatusNotFound, content: `{"title":"Not Found","status":404,"detail":"id 2 not found"}`},
+
+		// create
+		{method: "POST", path: "/persons", body: `{"name":"Grace Hopper"}`, status: http.StatusCreated, location: "/persons/6", content: `{"id":6,"name":"Grace Hopper"}`},
+		{method: "POST", path: "/persons", body: `{"id":1,"name":"Someone"}`, status: http.StatusConflict, content: `{"title":"Conflict","status":409,"detail":"id 1 already exists"}`},
+		{method: "POST", path: "/persons", body: `not json`, status: http.StatusBadRequest, content: `{"title":"Bad Request","status":400,"detail":"invalid character 'o' in literal null (expecting 'u')"}`},
+
+		// update
+		{method: "PATCH", path: "/persons/1", body: `{"cool":null,"company":{"name":"Tesla"}}`, status: http.StatusOK, content: `{"company":{"name":"Tesla"},"id":1,"name":"Elon Musk"}`},
+		{method: "PATCH", path: "/persons/9", body: `{"name":"Nobody"}`, status: http.StatusNotFound, content: `{"title":"Not Found","status":404,"detail":"id 9 not found"}`},
+		{method: "PUT", path: "/persons/5", body: `{"id":99,"name":"Ada King"}`, status: http.StatusOK, content: `{"id":5,"name":"Ada King"}`},
+		{method: "PUT", path: "/persons/7", body: `{"name":"Alan Turing"}`, status: http.StatusCreated, content: `{"id":7,"name":"Alan Turing"}`},
+
+		// delete
+		{method: "DELETE", path: "/persons/6", status: http.StatusNoContent},
+		{method: "DELETE", path: "/persons/6", status: http.StatusNotFound, content: `{"title":"Not Found","status":404,"detail":"id 6 not found"}`},
+
+		// pagination
+		{method: "GET", path: "/persons?per_page=2", status: http.StatusOK, content: `[{"company":{"name":"Tesla"},"id":1,"name":"Elon Musk"},{"id":5,"name":"Ada King"}]`},
+		{method: "GET", path: "/persons?page=2&per_page=2", status: http.StatusOK, content: `[{"id":7,"name":"Alan Turing"}]`},
+		{method: "GET", path: "/persons?page=3&per_page=2", status: http.StatusOK, content: `[]`},
+		{method: "GET", path: "/persons?page=4611686018427387905&per_page=2", status: http.StatusOK, content: `[]`},
+		{method: "GET", path: "/persons?per_page=9223372036854775807", status: http.StatusOK, content: `[{"company":{"name":"Tesla"},"id":1,"name":"Elon Musk"},{"id":5,"name":"Ada King"},{"id":7,"name":"Alan Turing"}]`},
+		{method: "GET", path: "/persons?page=2&per_page=9223372036854775807", status: http.StatusOK, content: `[]`},
+		{method: "GET", path: "/persons?page=0", status: http.StatusBadRequest, content: `{"title":"Bad Request","status":400,"detail":"invalid page"}`},
+
+		// others
+		{method: "DELETE", path: "/persons", status: http.StatusMethodNotAllowed, content: `{"title":"Method Not Allowed","status":405}`},
+		{method: "GET", path: "/companies/1", status: http.StatusNotFound, content: `{"title":"Not Found","status":404}`},
+	}
+
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, "https://api.example.com"+test.path, strings.NewReader(test.body))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := test.status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		if want, have := test.location, resp.Header.Get("Location"); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+		content, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.content, string(content); want != have {
+			t.Errorf("[%d] expected %s, got %s", i, want, have)
+		}
+	}
+
+	// inspect the state
+	if want, have := 3, res.Len(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	item, found := res.Item("7")
+	if !found {
+		t.Fatalf("expected item 7 to be found")
+	}
+	if want, have := "Alan Turing", item["name"]; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	item["name"] = "modified"
+	if item, _ = res.Item("7"); item["name"] != "Alan Turing" {
+		t.Errorf("expected Item to return a copy, got %#v", item)
+	}
+	if _, found = res.Item("6"); found {
+		t.Errorf("expected item 6 to be deleted")
+	}
+}
+
+func TestResourceSimulator_totalCount(t *testing.T) {
+	res := mockhttp.NewResourceSimulator("/items")
+	for i := 1; i <= 25; i++ {
+		res.Seed(map[string]interface{}{"id": fmt.Sprintf("item-%02d", i)})
+	}
+	resp, err := mockhttp.NewClient(res).Get("https://api.example.com/items?page=3&per_page=10")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := "25", resp.Header.Get("X-Total-Count"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := `[{"id":"item-21"},{"id":"item-22"},{"id":"item-23"},{"id":"item-24"},{"id":"item-25"}]`, string(content); want != have {
+		t.Errorf("expected %s, got %s", want, have)
+	}
+}
+
+func TestResourceSimulator_zeroValue(t *testing.T) {
+	res := &mockhttp.ResourceSimulator{Path: "/items"}
+	if err := res.Seed(map[string]interface{}{"id": 1, "name": "foo"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := mockhttp.NewClient(res)
+	resp, err := client.Post("https://api.example.com/items", "application/json", strings.NewReader(`{"name":"bar"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusCreated, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "/items/2", resp.Header.Get("Location"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := 2, res.Len(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	empty := &mockhttp.ResourceSimulator{Path: "/items"}
+	req, _ := http.NewRequest("PUT", "https://api.example.com/items/foo", strings.NewReader(`{"name":"foo"}`))
+	resp, err = mockhttp.NewClient(empty).Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, have := http.StatusCreated, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if _, found := empty.Item("foo"); !found {
+		t.Errorf("expected item foo, found none")
+	}
+}
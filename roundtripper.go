@@ -2,7 +2,11 @@ package mockhttp
 
 import (
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 )
 
 // RoundTripperFunc is a simplified way to implement http.RoundTripper
@@ -13,7 +17,23 @@ func (rt RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
 	return rt(r)
 }
 
-// MuxRoundTripper mux http.RoundTripper by the request's URL.Host field
+// MuxRoundTripper mux http.RoundTripper by the request's URL.Host field.
+//
+// Besides exact host names, the keys of the mux may be host patterns
+// in the form "[scheme://]host[:port]":
+//
+//	api.example.com             any scheme, any port
+//	api.example.com:8080        any scheme, port 8080
+//	https://api.example.com     https only, any port
+//	*.example.com               any subdomain of example.com
+//
+// Requests without explicit port are considered to be on the default
+// port of their scheme (i.e. 80 for http and 443 for https).
+//
+// If more than one pattern matches a request, the most specific one
+// wins: exact host over subdomain wildcard, then the longer host
+// pattern, then pattern with port, then pattern with scheme.
+// The key "*" matches any request as the last resort.
 type MuxRoundTripper map[string]http.RoundTripper
 
 // NewMuxRoundTripper returns a new NewMuxRoundTripper
@@ -35,30 +55,254 @@ func (mux MuxRoundTripper) AddFunc(host string, fn RoundTripperFunc) {
 	mux[host] = fn
 }
 
-// Get the http.RoundTripper for the given host
+// Remove the http.RoundTripper of the host, if any.
+func (mux MuxRoundTripper) Remove(host string) {
+	delete(mux, host)
+}
+
+// Get the http.RoundTripper for the given host. Patterns with
+// scheme will not match.
 func (mux MuxRoundTripper) Get(host string) (http.RoundTripper, error) {
-	if rt, found := mux[host]; found {
-		return rt, nil // RoundTripper with match host
+	return mux.lookup("", host)
+}
+
+// GetURL gets the http.RoundTripper for the given URL.
+func (mux MuxRoundTripper) GetURL(u *url.URL) (http.RoundTripper, error) {
+	return mux.lookup(u.Scheme, u.Host)
+}
+
+// lookup the http.RoundTripper for the scheme and host.
+func (mux MuxRoundTripper) lookup(scheme, host string) (http.RoundTripper, error) {
+	var matched *hostPattern
+	var matchedRT http.RoundTripper
+	for key, rt := range mux {
+		if key == "*" {
+			continue
+		}
+		pattern := parseHostPattern(key)
+		if pattern.match(scheme, host) && (matched == nil || pattern.moreSpecific(*matched)) {
+			matched, matchedRT = &pattern, rt
+		}
+	}
+	if matched != nil {
+		return matchedRT, nil // RoundTripper with match host
 	}
+
 	if rt, found := mux["*"]; found {
 		return rt, nil // fallback RoundTripper
 	}
-	return nil, fmt.Errorf("no http.RoundTripper found for host %s",
-		host)
+	return nil, mux.noRouteError(host)
+}
+
+// noRouteError builds a NoRouteError for the host
+func (mux MuxRoundTripper) noRouteError(host string) *NoRouteError {
+	err := &NoRouteError{Host: host}
+	hostname, _ := splitHostPort("", host)
+	for key := range mux {
+		err.Routes = append(err.Routes, key)
+	}
+	sort.Strings(err.Routes)
+
+	distance := -1
+	for _, key := range err.Routes {
+		if d := levenshtein(hostname, parseHostPattern(key).host); distance == -1 || d < distance {
+			distance, err.Closest = d, key
+		}
+	}
+	return err
 }
 
 // RoundTrip implements http.RoundTripper
 func (mux MuxRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
-	rt, err := mux.Get(r.URL.Host)
+	rt, err := mux.GetURL(r.URL)
 	if err != nil {
+		if nrErr, ok := err.(*NoRouteError); ok {
+			nrErr.Request = r
+		}
 		return nil, err
 	}
 	return rt.RoundTrip(r)
 }
 
-// NewClient returns a new http.Client with the mux as transport
-func (mux MuxRoundTripper) NewClient() *http.Client {
-	return &http.Client{
-		Transport: mux,
+// Strict returns an http.RoundTripper of the mux that reports the
+// details of every unmatched request to t, in addition to returning
+// the NoRouteError.
+func (mux MuxRoundTripper) Strict(t TestingT) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := mux.RoundTrip(r)
+		if nrErr, ok := err.(*NoRouteError); ok {
+			t.Helper()
+			t.Errorf("%s", nrErr.Details())
+		}
+		return resp, err
+	})
+}
+
+// NewClient returns a new http.Client with the mux as transport.
+// The client is then configured by the options in order.
+func (mux MuxRoundTripper) NewClient(options ...ClientOption) *http.Client {
+	return NewClient(mux, options...)
+}
+
+// TestingT is the subset of testing.TB used for reporting
+// errors to the test.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// NoRouteError is the error returned by MuxRoundTripper when there is
+// no http.RoundTripper found for a request.
+type NoRouteError struct {
+	// Host of the request.
+	Host string
+
+	// Request that found no route. It is nil if the error is
+	// returned by MuxRoundTripper.Get or MuxRoundTripper.GetURL.
+	Request *http.Request
+
+	// Routes are all the keys registered in the mux, sorted.
+	Routes []string
+
+	// Closest is the route that is most similar to the host.
+	// Empty if there is no route at all.
+	Closest string
+}
+
+// Error implements error
+func (err *NoRouteError) Error() string {
+	return fmt.Sprintf("no http.RoundTripper found for host %s", err.Host)
+}
+
+// Details returns a multiple lines description of the error,
+// with the request and routes available, for debugging.
+func (err *NoRouteError) Details() string {
+	var b strings.Builder
+	b.WriteString(err.Error())
+	if err.Request != nil {
+		fmt.Fprintf(&b, "\nrequest: %s %s", err.Request.Method, err.Request.URL)
+		keys := make([]string, 0, len(err.Request.Header))
+		for key := range err.Request.Header {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, value := range err.Request.Header[key] {
+				fmt.Fprintf(&b, "\n    %s: %s", key, value)
+			}
+		}
+	}
+	if len(err.Routes) == 0 {
+		b.WriteString("\nroutes: (none)")
+		return b.String()
+	}
+	b.WriteString("\nroutes:")
+	for _, route := range err.Routes {
+		fmt.Fprintf(&b, "\n    %s", route)
+	}
+	fmt.Fprintf(&b, "\nclosest match: %s", err.Closest)
+	return b.String()
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < curr[j] {
+				curr[j] = d
+			}
+			if d := curr[j-1] + 1; d < curr[j] {
+				curr[j] = d
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// hostPattern is the parsed form of a MuxRoundTripper key.
+type hostPattern struct {
+	raw      string
+	scheme   string
+	host     string // host name, or the suffix (e.g. ".example.com") if wildcard
+	port     string
+	wildcard bool
+}
+
+// defaultPorts of the schemes
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// splitHostPort splits host into host name and port. If host has no
+// port, the default port of the scheme is returned instead.
+func splitHostPort(scheme, host string) (hostname, port string) {
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, port = strings.Trim(host, "[]"), defaultPorts[scheme]
+	}
+	return strings.ToLower(hostname), port
+}
+
+// parseHostPattern parses a MuxRoundTripper key into hostPattern.
+func parseHostPattern(key string) (pattern hostPattern) {
+	pattern.raw = key
+	rest := key
+	if i := strings.Index(rest, "://"); i != -1 {
+		pattern.scheme, rest = strings.ToLower(rest[:i]), rest[i+3:]
+	}
+	if hostname, port, err := net.SplitHostPort(rest); err == nil {
+		pattern.host, pattern.port = hostname, port
+	} else {
+		pattern.host = strings.Trim(rest, "[]")
+	}
+	pattern.host = strings.ToLower(pattern.host)
+	if strings.HasPrefix(pattern.host, "*.") {
+		pattern.wildcard, pattern.host = true, pattern.host[1:]
+	}
+	return
+}
+
+// match reports if the pattern matches the scheme and host
+func (pattern hostPattern) match(scheme, host string) bool {
+	if pattern.scheme != "" && pattern.scheme != strings.ToLower(scheme) {
+		return false
+	}
+	hostname, port := splitHostPort(strings.ToLower(scheme), host)
+	if pattern.port != "" && pattern.port != port {
+		return false
+	}
+	if pattern.wildcard {
+		return strings.HasSuffix(hostname, pattern.host)
+	}
+	return hostname == pattern.host
+}
+
+// moreSpecific reports if the pattern is more specific than other
+func (pattern hostPattern) moreSpecific(other hostPattern) bool {
+	if pattern.wildcard != other.wildcard {
+		return !pattern.wildcard
+	}
+	if len(pattern.host) != len(other.host) {
+		return len(pattern.host) > len(other.host)
+	}
+	if (pattern.port != "") != (other.port != "") {
+		return pattern.port != ""
+	}
+	if (pattern.scheme != "") != (other.scheme != "") {
+		return pattern.scheme != ""
 	}
+	return pattern.raw < other.raw // for deterministic result
 }
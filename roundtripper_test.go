@@ -1,6 +1,7 @@
 package mockhttp_test
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -136,6 +137,112 @@ func TestMuxRoundTripper_Get_error(t *testing.T) {
 
 }
 
+func TestMuxRoundTripper_hostPattern(t *testing.T) {
+	mux := mockhttp.MuxRoundTripper{}
+	mux.Add("api.example.com", mockhttp.StaticResponseRT("api", "text/plain"))
+	mux.Add("api.example.com:8080", mockhttp.StaticResponseRT("api 8080", "text/plain"))
+	mux.Add("http://api.example.com", mockhttp.StaticResponseRT("api http", "text/plain"))
+	mux.Add("*.example.com", mockhttp.StaticResponseRT("example subdomain", "text/plain"))
+	mux.Add("*.cdn.example.com", mockhttp.StaticResponseRT("cdn subdomain", "text/plain"))
+	mux.Add("https://*.example.com:443", mockhttp.StaticResponseRT("example subdomain https", "text/plain"))
+	mux.Add("*", mockhttp.StaticResponseRT("fallback", "text/plain"))
+
+	tests := []struct {
+		url string
+		res string
+	}{
+		{url: "https://api.example.com/", res: "api"},
+		{url: "https://API.Example.com:443/", res: "api"},
+		{url: "https://api.example.com:8080/", res: "api 8080"},
+		{url: "http://api.example.com/", res: "api http"},
+		{url: "http://api.example.com:80/", res: "api http"},
+		{url: "http://api.example.com:8080/", res: "api 8080"},
+		{url: "http://www.example.com/", res: "example subdomain"},
+		{url: "https://www.example.com/", res: "example subdomain https"},
+		{url: "https://img.cdn.example.com/", res: "cdn subdomain"},
+		{url: "https://example.com/", res: "fallback"},
+		{url: "https://www.foobar.com/", res: "fallback"},
+	}
+
+	for i, test := range tests {
+		resp, err := mux.NewClient().Get(test.url)
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		c, _ := ioutil.ReadAll(resp.Body)
+		if want, have := test.res, string(c); want != have {
+			t.Errorf("[%d] %s: expected %#v, got %#v", i, test.url, want, have)
+		}
+	}
+}
+
+func TestMuxRoundTripper_NoRouteError(t *testing.T) {
+	mux := mockhttp.MuxRoundTripper{}
+	mux.Add("api.example.com", mockhttp.StaticResponseRT("api", "text/plain"))
+	mux.Add("www.example.com", mockhttp.StaticResponseRT("www", "text/plain"))
+
+	req, _ := http.NewRequest("POST", "https://api.exmaple.com/users?id=1", nil)
+	req.Header.Set("X-Foo", "bar")
+	_, err := mux.NewClient().Do(req)
+
+	var nrErr *mockhttp.NoRouteError
+	if !errors.As(err, &nrErr) {
+		t.Errorf("expected *mockhttp.NoRouteError, got %#v", err)
+		return
+	}
+	if want, have := "api.exmaple.com", nrErr.Host; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if nrErr.Request == nil {
+		t.Errorf("expected request, got nil")
+	}
+	if want, have := "api.example.com", nrErr.Closest; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `no http.RoundTripper found for host api.exmaple.com
+request: POST https://api.exmaple.com/users?id=1
+    X-Foo: bar
+routes:
+    api.example.com
+    www.example.com
+closest match: api.example.com`, nrErr.Details(); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+type mockTestingT struct {
+	errors []string
+}
+
+func (t *mockTestingT) Helper() {}
+
+func (t *mockTestingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMuxRoundTripper_Strict(t *testing.T) {
+	mux := mockhttp.MuxRoundTripper{}
+	mux.Add("api.example.com", mockhttp.StaticResponseRT("api", "text/plain"))
+
+	mt := &mockTestingT{}
+	client := &http.Client{Transport: mux.Strict(mt)}
+	if _, err := client.Get("https://api.example.com/"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if want, have := 0, len(mt.errors); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if _, err := client.Get("https://www.example.com/"); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	if want, have := 1, len(mt.errors); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	} else if !strings.HasPrefix(mt.errors[0], "no http.RoundTripper found for host www.example.com\nrequest: GET https://www.example.com/") {
+		t.Errorf("unexpected error report: %s", mt.errors[0])
+	}
+}
+
 func TestMuxRoundTripper_NewClient(t *testing.T) {
 	mux := mockhttp.MuxRoundTripper{}
 	mux.AddFunc("www.google.com", func(r *http.Request) (resp *http.Response, err error) {
@@ -0,0 +1,101 @@
+package mockhttp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+)
+
+// RoundTripperHandler implements http.Handler by passing the requests
+// it serves to the http.RoundTripper, and writes back the response.
+//
+// The request URL is rebuilt from the Host header and the connection
+// (http or https), so MuxRoundTripper can route the requests by their
+// virtual host. If the http.RoundTripper returns an error, the handler
+// responds 502 Bad Gateway with the error message as body.
+func RoundTripperHandler(rt http.RoundTripper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outreq := r.Clone(r.Context())
+		outreq.RequestURI = ""
+		outreq.URL.Host = r.Host
+		outreq.URL.Scheme = "http"
+		if r.TLS != nil {
+			outreq.URL.Scheme = "https"
+		}
+
+		resp, err := rt.RoundTrip(outreq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeResponse(w, resp)
+	})
+}
+
+// writeResponse writes the response to the http.ResponseWriter
+// and closes the response body.
+func writeResponse(w http.ResponseWriter, resp *http.Response) {
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	for key := range resp.Trailer {
+		w.Header().Add("Trailer", key)
+	}
+	w.WriteHeader(resp.StatusCode)
+	if resp.Body != nil {
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	}
+	for key, values := range resp.Trailer {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+}
+
+// Server is a local HTTP server, listening on the loopback interface,
+// that serves requests with an http.RoundTripper. It allows the same
+// mock definitions to be used by code that cannot be given a custom
+// transport (e.g. subprocesses, third-party SDKs).
+type Server struct {
+	*httptest.Server
+}
+
+// NewServer starts and returns a new Server that serves requests
+// with the http.RoundTripper. The caller should call Close when
+// finished, to shut it down.
+func NewServer(rt http.RoundTripper) *Server {
+	return &Server{httptest.NewServer(RoundTripperHandler(rt))}
+}
+
+// NewTLSServer starts and returns a new Server using HTTPS, with a
+// self-signed certificate, that serves requests with the
+// http.RoundTripper. The caller should call Close when finished,
+// to shut it down.
+func NewTLSServer(rt http.RoundTripper) *Server {
+	return &Server{httptest.NewTLSServer(RoundTripperHandler(rt))}
+}
+
+// Client returns an http.Client that sends requests of any host to
+// the server, so the requests can be routed by their virtual host.
+// For TLS server, the client trusts the server certificate no matter
+// the host name.
+func (srv *Server) Client() *http.Client {
+	client := srv.Server.Client()
+	transport := client.Transport.(*http.Transport).Clone()
+	addr := srv.Listener.Addr().String()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+		transport.TLSClientConfig.ServerName = "example.com" // the host name in httptest certificate
+	}
+	client.Transport = transport
+	return client
+}
@@ -0,0 +1,75 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestServer(t *testing.T) {
+	mux := mockhttp.NewMuxRoundTripper()
+	mux.Add("api.example.com", mockhttp.StaticResponseRT("api", "text/plain"))
+	mux.Add("https://secure.example.com", mockhttp.StaticResponseRT("secure", "text/plain"))
+	mux.Add("cdn.example.com", mockhttp.FileSystemRT("./testdata"))
+	mux.Add("*", mockhttp.TransportErrorRT(fmt.Errorf("no network")))
+
+	tests := []struct {
+		server  *mockhttp.Server
+		url     string
+		status  int
+		content string
+	}{
+		{server: mockhttp.NewServer(mux), url: "http://api.example.com/", status: http.StatusOK, content: "api"},
+		{server: mockhttp.NewServer(mux), url: "http://cdn.example.com/test.txt", status: http.StatusOK, content: "hello world"},
+		{server: mockhttp.NewServer(mux), url: "http://cdn.example.com/notfound.txt", status: http.StatusNotFound, content: "Not Found"},
+		{server: mockhttp.NewServer(mux), url: "http://secure.example.com/", status: http.StatusBadGateway, content: "no network\n"},
+		{server: mockhttp.NewTLSServer(mux), url: "https://secure.example.com/", status: http.StatusOK, content: "secure"},
+		{server: mockhttp.NewTLSServer(mux), url: "https://api.example.com/", status: http.StatusOK, content: "api"},
+	}
+
+	for i, test := range tests {
+		func() {
+			defer test.server.Close()
+			resp, err := test.server.Client().Get(test.url)
+			if err != nil {
+				t.Errorf("[%d] unexpected error: %s", i, err)
+				return
+			}
+			defer resp.Body.Close()
+			content, _ := ioutil.ReadAll(resp.Body)
+			if want, have := test.status, resp.StatusCode; want != have {
+				t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+			}
+			if want, have := test.content, string(content); want != have {
+				t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+			}
+		}()
+	}
+}
+
+func TestServer_directRequest(t *testing.T) {
+	var host string
+	srv := mockhttp.NewServer(mockhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		host = r.URL.Host
+		return mockhttp.StaticResponseRT("hello world", "text/plain")(r)
+	}))
+	defer srv.Close()
+
+	// the server is reachable like an ordinary server
+	resp, err := http.Get(srv.URL + "/hello")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+	content, _ := ioutil.ReadAll(resp.Body)
+	if want, have := "hello world", string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := srv.Listener.Addr().String(), host; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -0,0 +1,111 @@
+package mockhttp
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// SyncMuxRoundTripper is a MuxRoundTripper that is safe for
+// concurrent use. Routes may be added, removed or replaced while
+// requests are in flight.
+//
+// The zero value is an empty mux ready to use. A SyncMuxRoundTripper
+// must not be copied after first use.
+type SyncMuxRoundTripper struct {
+	lock   sync.RWMutex
+	routes MuxRoundTripper
+}
+
+// NewSyncMuxRoundTripper returns a new SyncMuxRoundTripper
+func NewSyncMuxRoundTripper() *SyncMuxRoundTripper {
+	return &SyncMuxRoundTripper{
+		routes: make(MuxRoundTripper),
+	}
+}
+
+// Add an http.RoundTripper to the mux with reference to the host.
+// See MuxRoundTripper for the supported host patterns.
+func (mux *SyncMuxRoundTripper) Add(host string, rt http.RoundTripper) {
+	mux.lock.Lock()
+	defer mux.lock.Unlock()
+	if mux.routes == nil {
+		mux.routes = make(MuxRoundTripper)
+	}
+	mux.routes.Add(host, rt)
+}
+
+// AddFunc add an RoundTripperFunc to the mux with reference to the host
+func (mux *SyncMuxRoundTripper) AddFunc(host string, fn RoundTripperFunc) {
+	mux.Add(host, fn)
+}
+
+// Remove the http.RoundTripper of the host, if any.
+func (mux *SyncMuxRoundTripper) Remove(host string) {
+	mux.lock.Lock()
+	defer mux.lock.Unlock()
+	mux.routes.Remove(host)
+}
+
+// Replace all the routes of the mux with a copy of routes.
+func (mux *SyncMuxRoundTripper) Replace(routes MuxRoundTripper) {
+	copied := make(MuxRoundTripper, len(routes))
+	for host, rt := range routes {
+		copied[host] = rt
+	}
+	mux.lock.Lock()
+	defer mux.lock.Unlock()
+	mux.routes = copied
+}
+
+// Clear removes all the routes of the mux.
+func (mux *SyncMuxRoundTripper) Clear() {
+	mux.Replace(nil)
+}
+
+// Snapshot returns a copy of the current routes of the mux. The
+// snapshot is not affected by later changes to the mux, and can
+// be restored with Replace.
+func (mux *SyncMuxRoundTripper) Snapshot() MuxRoundTripper {
+	mux.lock.RLock()
+	defer mux.lock.RUnlock()
+	snapshot := make(MuxRoundTripper, len(mux.routes))
+	for host, rt := range mux.routes {
+		snapshot[host] = rt
+	}
+	return snapshot
+}
+
+// Get the http.RoundTripper for the given host
+func (mux *SyncMuxRoundTripper) Get(host string) (http.RoundTripper, error) {
+	mux.lock.RLock()
+	defer mux.lock.RUnlock()
+	return mux.routes.Get(host)
+}
+
+// GetURL gets the http.RoundTripper for the given URL.
+func (mux *SyncMuxRoundTripper) GetURL(u *url.URL) (http.RoundTripper, error) {
+	mux.lock.RLock()
+	defer mux.lock.RUnlock()
+	return mux.routes.GetURL(u)
+}
+
+// RoundTrip implements http.RoundTripper. The lock is only held
+// while looking up the route, so the routes may be changed by
+// the http.RoundTripper handling the request.
+func (mux *SyncMuxRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt, err := mux.GetURL(r.URL)
+	if err != nil {
+		if nrErr, ok := err.(*NoRouteError); ok {
+			nrErr.Request = r
+		}
+		return nil, err
+	}
+	return rt.RoundTrip(r)
+}
+
+// NewClient returns a new http.Client with the mux as transport.
+// The client is then configured by the options in order.
+func (mux *SyncMuxRoundTripper) NewClient(options ...ClientOption) *http.Client {
+	return NewClient(mux, options...)
+}
@@ -0,0 +1,114 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestSyncMuxRoundTripper(t *testing.T) {
+	var mux mockhttp.SyncMuxRoundTripper // zero value is usable
+	mux.Add("api.example.com", mockhttp.StaticResponseRT("api", "text/plain"))
+	mux.Add("*", mockhttp.TransportErrorRT(fmt.Errorf("no network")))
+	client := mux.NewClient()
+
+	get := func() (string, error) {
+		resp, err := client.Get("https://api.example.com/")
+		if err != nil {
+			return "", err
+		}
+		content, err := ioutil.ReadAll(resp.Body)
+		return string(content), err
+	}
+
+	if content, err := get(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if want, have := "api", content; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// take the host down
+	snapshot := mux.Snapshot()
+	mux.Remove("api.example.com")
+	if _, err := get(); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+
+	// snapshot is not affected
+	if want, have := 2, len(snapshot); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// bring it back
+	mux.Replace(snapshot)
+	if content, err := get(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if want, have := "api", content; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	mux.Clear()
+	if _, err := mux.Get("api.example.com"); err == nil {
+		t.Errorf("expected error, got nil")
+	} else if _, ok := err.(*mockhttp.NoRouteError); !ok {
+		t.Errorf("expected *mockhttp.NoRouteError, got %#v", err)
+	}
+}
+
+func TestSyncMuxRoundTripper_concurrent(t *testing.T) {
+	mux := mockhttp.NewSyncMuxRoundTripper()
+	mux.Add("*", mockhttp.StaticResponseRT("fallback", "text/plain"))
+	client := mux.NewClient()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			host := fmt.Sprintf("host%d.example.com", i)
+			for j := 0; j < 50; j++ {
+				mux.Add(host, mockhttp.StaticResponseRT(host, "text/plain"))
+				mux.Snapshot()
+				mux.Remove(host)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				resp, err := client.Get(fmt.Sprintf("https://host%d.example.com/", i))
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+					return
+				}
+				resp.Body.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// routes can be changed by the http.RoundTripper handling the request
+func TestSyncMuxRoundTripper_reentrant(t *testing.T) {
+	mux := mockhttp.NewSyncMuxRoundTripper()
+	mux.AddFunc("api.example.com", func(r *http.Request) (*http.Response, error) {
+		mux.Remove("api.example.com") // fail once only
+		return mockhttp.ServerErrorRT(http.StatusServiceUnavailable)(r)
+	})
+	mux.Add("*", mockhttp.StaticResponseRT("recovered", "text/plain"))
+
+	client := mux.NewClient()
+	for i, status := range []int{http.StatusServiceUnavailable, http.StatusOK} {
+		resp, err := client.Get("https://api.example.com/")
+		if err != nil {
+			t.Errorf("[%d] unexpected error: %s", i, err)
+			continue
+		}
+		if want, have := status, resp.StatusCode; want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+}
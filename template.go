@@ -0,0 +1,161 @@
+package mockhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// TemplateResponse defines a response to be rendered with
+// text/template by TemplateRT. The body and header values are
+// templates executed with a TemplateData of the request.
+type TemplateResponse struct {
+	// Status is the status code of the response. Default 200.
+	Status int
+
+	// ContentType of the response. Default "text/plain; charset=utf-8".
+	ContentType string
+
+	// Header values templates of the response.
+	Header map[string]string
+
+	// Body template of the response.
+	Body string
+
+	// PathPattern, if set, is matched against the request URL path
+	// to extract path parameters. A segment in the form "{name}"
+	// matches any single path segment and is stored in
+	// TemplateData.Params. Requests that do not match the pattern
+	// will get a 404 response.
+	PathPattern string
+}
+
+// TemplateData is the data available to templates of a
+// TemplateResponse.
+type TemplateData struct {
+	Request *http.Request
+	Method  string
+	Path    string
+	Params  map[string]string
+	Query   url.Values
+	Header  http.Header
+
+	// Body is the raw request body.
+	Body string
+
+	// JSON is the request body decoded as JSON, if possible.
+	// Otherwise nil.
+	JSON interface{}
+}
+
+// templateFuncs are the extra functions available to templates
+// of a TemplateResponse.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		content, err := json.Marshal(v)
+		return string(content), err
+	},
+}
+
+// matchPathPattern matches the path against the pattern and returns
+// the path parameters. If the path does not match, ok will be false.
+func matchPathPattern(pattern, path string) (params map[string]string, ok bool) {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+	params = make(map[string]string)
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			value, err := url.PathUnescape(pathSegments[i])
+			if err != nil {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = value
+		} else if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// TemplateRT returns an http.RoundTripper that renders the response
+// body and headers from the templates in tr, with the TemplateData of
+// each request. Besides the builtin functions, a "json" function is
+// available in templates to encode any value as JSON.
+//
+// For example, to echo back the id in URL path:
+//
+//	mockhttp.TemplateRT(mockhttp.TemplateResponse{
+//		ContentType: "application/json",
+//		Body:        `{"id": {{.Params.id}}, "name": {{json .JSON.name}}}`,
+//		PathPattern: "/users/{id}",
+//	})
+//
+// If any of the templates cannot be parsed, or failed to execute,
+// the RoundTripper will return the error as transport error.
+func TemplateRT(tr TemplateResponse) RoundTripperFunc {
+	if tr.Status == 0 {
+		tr.Status = http.StatusOK
+	}
+	if tr.ContentType == "" {
+		tr.ContentType = "text/plain; charset=utf-8"
+	}
+
+	bodyTmpl, err := template.New("body").Funcs(templateFuncs).Parse(tr.Body)
+	if err != nil {
+		return TransportErrorRT(fmt.Errorf("error parsing body template: %s", err))
+	}
+	headerTmpls := make(map[string]*template.Template, len(tr.Header))
+	for key, value := range tr.Header {
+		if headerTmpls[key], err = template.New(key).Funcs(templateFuncs).Parse(value); err != nil {
+			return TransportErrorRT(fmt.Errorf("error parsing header template %#v: %s", key, err))
+		}
+	}
+
+	return func(r *http.Request) (*http.Response, error) {
+		params := map[string]string{}
+		if tr.PathPattern != "" {
+			var ok bool
+			if params, ok = matchPathPattern(tr.PathPattern, r.URL.Path); !ok {
+				return ServerErrorRT(http.StatusNotFound)(r)
+			}
+		}
+
+		content, err := readBody(r)
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %s", err)
+		}
+		data := TemplateData{
+			Request: r,
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Params:  params,
+			Query:   r.URL.Query(),
+			Header:  r.Header,
+			Body:    string(content),
+		}
+		if err := json.Unmarshal(content, &data.JSON); err != nil {
+			data.JSON = nil
+		}
+
+		var body bytes.Buffer
+		if err := bodyTmpl.Execute(&body, data); err != nil {
+			return nil, fmt.Errorf("error executing body template: %s", err)
+		}
+		resp := bytesResponse(r, tr.Status, tr.ContentType, body.Bytes())
+		for key, tmpl := range headerTmpls {
+			var value bytes.Buffer
+			if err := tmpl.Execute(&value, data); err != nil {
+				return nil, fmt.Errorf("error executing header template %#v: %s", key, err)
+			}
+			resp.Header.Set(key, value.String())
+		}
+		return resp, nil
+	}
+}
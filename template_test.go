@@ -0,0 +1,100 @@
+package mockhttp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestTemplateRT(t *testing.T) {
+	client := &http.Client{
+		Transport: mockhttp.TemplateRT(mockhttp.TemplateResponse{
+			Status:      http.StatusCreated,
+			ContentType: "application/json",
+			Header: map[string]string{
+				"Location":  "/users/{{.Params.id}}",
+				"X-Request": "{{.Method}} {{.Path}}?{{.Query.Encode}}",
+				"X-Token":   `{{.Header.Get "Authorization"}}`,
+			},
+			Body:        `{"id": {{.Params.id}}, "name": {{json .JSON.name}}}`,
+			PathPattern: "/users/{id}",
+		}),
+	}
+
+	req, _ := http.NewRequest("PUT", "https://api.foobar.com/users/42?dry=1",
+		strings.NewReader(`{"name": "Elon Musk"}`))
+	req.Header.Set("Authorization", "Bearer abc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+
+	if want, have := http.StatusCreated, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := `{"id": 42, "name": "Elon Musk"}`, string(content); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := fmt.Sprintf("%d", len(content)), resp.Header.Get("Content-Length"); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	headerTests := []struct {
+		key   string
+		value string
+	}{
+		{key: "Content-Type", value: "application/json"},
+		{key: "Location", value: "/users/42"},
+		{key: "X-Request", value: "PUT /users/42?dry=1"},
+		{key: "X-Token", value: "Bearer abc"},
+	}
+	for i, test := range headerTests {
+		if want, have := test.value, resp.Header.Get(test.key); want != have {
+			t.Errorf("[%d] expected %#v, got %#v", i, want, have)
+		}
+	}
+
+	// path not matching the pattern
+	resp, err = client.Get("https://api.foobar.com/groups/42")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if want, have := http.StatusNotFound, resp.StatusCode; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
+
+func TestTemplateRT_error(t *testing.T) {
+	tests := []mockhttp.TemplateResponse{
+		{Body: "{{.Method"},
+		{Header: map[string]string{"X-Foo": "{{end}}"}},
+		{Body: "{{.NoSuchField}}"},
+	}
+	for i, test := range tests {
+		client := &http.Client{Transport: mockhttp.TemplateRT(test)}
+		if _, err := client.Get("https://api.foobar.com/"); err == nil {
+			t.Errorf("[%d] expected error, got nil", i)
+		}
+	}
+}
+
+func ExampleTemplateRT() {
+	client := &http.Client{
+		Transport: mockhttp.TemplateRT(mockhttp.TemplateResponse{
+			ContentType: "application/json",
+			Body:        `{"id": {{.Params.id}}, "fields": {{json (index .Query "fields")}}}`,
+			PathPattern: "/persons/{id}",
+		}),
+	}
+
+	resp, _ := client.Get("https://api.foobar.com/persons/12?fields=name&fields=cool")
+	content, _ := ioutil.ReadAll(resp.Body)
+	fmt.Printf("%s\n", content)
+
+	// Output: {"id": 12, "fields": ["name","cool"]}
+}
@@ -0,0 +1,136 @@
+package mockhttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TLSConfig configures the synthetic TLS connection state attached
+// to responses by SimulateTLS.
+type TLSConfig struct {
+	// Version of TLS. Default tls.VersionTLS13.
+	Version uint16
+
+	// CipherSuite negotiated. Default tls.TLS_AES_128_GCM_SHA256.
+	CipherSuite uint16
+
+	// NegotiatedProtocol is the protocol negotiated by ALPN
+	// (e.g. "h2"). Default empty.
+	NegotiatedProtocol string
+}
+
+// SimulateTLS is a Middleware that attaches a synthetic
+// tls.ConnectionState to the responses of https requests, as if
+// the connection were established with a server certificate of
+// the request host. The certificate is issued by a certificate
+// authority generated for each SimulateTLS (see RootCAs).
+//
+// Responses of non-https requests are not modified.
+type SimulateTLS struct {
+	config TLSConfig
+	certs  *hostCerts
+}
+
+// NewSimulateTLS returns a new SimulateTLS with the config
+func NewSimulateTLS(config TLSConfig) *SimulateTLS {
+	if config.Version == 0 {
+		config.Version = tls.VersionTLS13
+	}
+	if config.CipherSuite == 0 {
+		config.CipherSuite = tls.TLS_AES_128_GCM_SHA256
+	}
+	return &SimulateTLS{
+		config: config,
+		certs:  newHostCerts("mockhttp TLS simulation CA"),
+	}
+}
+
+// RootCAs returns a pool with the certificate authority that
+// issued the certificates in the connection states.
+func (st *SimulateTLS) RootCAs() *x509.CertPool {
+	return st.certs.ca.certPool()
+}
+
+// ConnectionState returns the synthetic connection state of the host
+func (st *SimulateTLS) ConnectionState(host string) *tls.ConnectionState {
+	cert := st.certs.get(host)
+	chain := []*x509.Certificate{cert.Leaf, st.certs.ca.cert}
+	return &tls.ConnectionState{
+		Version:            st.config.Version,
+		HandshakeComplete:  true,
+		CipherSuite:        st.config.CipherSuite,
+		NegotiatedProtocol: st.config.NegotiatedProtocol,
+		ServerName:         host,
+		PeerCertificates:   chain,
+		VerifiedChains:     [][]*x509.Certificate{chain},
+	}
+}
+
+// Wrap implements Middleware
+func (st *SimulateTLS) Wrap(inner http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := inner.RoundTrip(r)
+		if resp != nil && r.URL.Scheme == "https" {
+			resp.TLS = st.ConnectionState(r.URL.Hostname())
+		}
+		return resp, err
+	})
+}
+
+// TLSFailure is a kind of certificate verification failure
+// simulated by TLSErrorRT.
+type TLSFailure int
+
+// Supported TLSFailure
+const (
+	// TLSExpiredCertificate simulates a server certificate that
+	// is expired (x509.CertificateInvalidError with x509.Expired).
+	TLSExpiredCertificate TLSFailure = iota
+
+	// TLSUnknownAuthority simulates a server certificate signed by
+	// an unknown authority (x509.UnknownAuthorityError).
+	TLSUnknownAuthority
+
+	// TLSHostnameMismatch simulates a server certificate that is
+	// not valid for the request host (x509.HostnameError).
+	TLSHostnameMismatch
+)
+
+// TLSErrorRT returns an http.RoundTripper that always fails with
+// the certificate verification error of the given kind, like
+// http.Transport does. The error is a *tls.CertificateVerificationError
+// that wraps the crypto/x509 error, so it can be inspected with
+// errors.As.
+func TLSErrorRT(failure TLSFailure) RoundTripperFunc {
+	certs := newHostCerts("mockhttp untrusted CA")
+	return func(r *http.Request) (*http.Response, error) {
+		host := r.URL.Hostname()
+		var cert *x509.Certificate
+		var err error
+		switch failure {
+		case TLSExpiredCertificate:
+			cert = certs.ca.issue(host, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour)).Leaf
+			err = x509.CertificateInvalidError{
+				Cert:   cert,
+				Reason: x509.Expired,
+				Detail: fmt.Sprintf("current time %s is after %s",
+					time.Now().UTC().Format(time.RFC3339), cert.NotAfter.UTC().Format(time.RFC3339)),
+			}
+		case TLSUnknownAuthority:
+			cert = certs.get(host).Leaf
+			err = x509.UnknownAuthorityError{Cert: cert}
+		case TLSHostnameMismatch:
+			cert = certs.get("mismatch.invalid").Leaf
+			err = x509.HostnameError{Certificate: cert, Host: host}
+		default:
+			return nil, fmt.Errorf("unknown TLS failure %d", failure)
+		}
+		return nil, &tls.CertificateVerificationError{
+			UnverifiedCertificates: []*x509.Certificate{cert, certs.ca.cert},
+			Err:                    err,
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package mockhttp_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/yookoala/mockhttp"
+)
+
+func TestSimulateTLS(t *testing.T) {
+	st := mockhttp.NewSimulateTLS(mockhttp.TLSConfig{
+		Version:            tls.VersionTLS12,
+		CipherSuite:        tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		NegotiatedProtocol: "h2",
+	})
+	client := mockhttp.NewClient(mockhttp.StaticResponseRT("hello world", "text/plain"),
+		mockhttp.ClientMiddleware(st))
+
+	resp, err := client.Get("https://api.example.com:8443/")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+	if resp.TLS == nil {
+		t.Errorf("expected TLS connection state, got nil")
+		return
+	}
+	if want, have := uint16(tls.VersionTLS12), resp.TLS.Version; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := uint16(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256), resp.TLS.CipherSuite; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "h2", resp.TLS.NegotiatedProtocol; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+	if want, have := "api.example.com", resp.TLS.ServerName; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	// the peer certificate should be verifiable with the root CAs
+	if want, have := 2, len(resp.TLS.PeerCertificates); want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+		return
+	}
+	if _, err := resp.TLS.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName: "api.example.com",
+		Roots:   st.RootCAs(),
+	}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// plain http response has no TLS state
+	resp, err = client.Get("http://api.example.com/")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if resp.TLS != nil {
+		t.Errorf("expected nil, got %#v", resp.TLS)
+	}
+}
+
+func TestTLSErrorRT(t *testing.T) {
+	var verificationErr *tls.CertificateVerificationError
+	var invalidErr x509.CertificateInvalidError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+
+	client := mockhttp.NewClient(mockhttp.TLSErrorRT(mockhttp.TLSExpiredCertificate))
+	_, err := client.Get("https://api.example.com/")
+	if !errors.As(err, &verificationErr) {
+		t.Errorf("expected *tls.CertificateVerificationError, got %#v", err)
+	}
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("expected x509.CertificateInvalidError, got %#v", err)
+	} else if want, have := x509.Expired, invalidErr.Reason; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	client = mockhttp.NewClient(mockhttp.TLSErrorRT(mockhttp.TLSUnknownAuthority))
+	_, err = client.Get("https://api.example.com/")
+	if !errors.As(err, &authorityErr) {
+		t.Errorf("expected x509.UnknownAuthorityError, got %#v", err)
+	} else if want, have := "api.example.com", authorityErr.Cert.Subject.CommonName; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+
+	client = mockhttp.NewClient(mockhttp.TLSErrorRT(mockhttp.TLSHostnameMismatch))
+	_, err = client.Get("https://api.example.com/")
+	if !errors.As(err, &hostnameErr) {
+		t.Errorf("expected x509.HostnameError, got %#v", err)
+	} else if want, have := "api.example.com", hostnameErr.Host; want != have {
+		t.Errorf("expected %#v, got %#v", want, have)
+	}
+}
@@ -2,11 +2,13 @@ package mockhttp
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"mime"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -102,90 +104,295 @@ func StaticResponseRT(content, contentType string) RoundTripperFunc {
 	}
 }
 
-// FileSystemRT implements http.RoundTripper by returning
-// contents of files in a given folder (as defined as `root`).
-func FileSystemRT(root string) RoundTripperFunc {
-	return func(r *http.Request) (resp *http.Response, err error) {
+// fileSystemConfig is the configuration of FileSystemRT
+type fileSystemConfig struct {
+	precompressed  []string
+	cacheControl   string
+	interruptAfter int64
+	overlay        *fileOverlay
+	errorPages     map[int]string
+	listing        DirListing
+	indexes        []string
+	openFile       func(name string) (file, error)
+}
+
+// FileSystemOption configures FileSystemRT
+type FileSystemOption func(config *fileSystemConfig)
 
-		path := filepath.Join(root, r.URL.Path)
-		f, err := os.Open(path)
-
-		if os.IsNotExist(err) {
-			// if path not found: 404
-			statusText := http.StatusText(http.StatusNotFound)
-			size := int64(len(statusText))
-			header := make(http.Header)
-			header.Add("Content-Length", fmt.Sprintf("%d", size))
-			header.Add("Content-Type", "text/plain")
-			header.Add("Date", time.Now().Format(time.RFC1123))
-			resp = &http.Response{
-				Status:        statusText,
-				StatusCode:    http.StatusNotFound,
-				Proto:         r.Proto,
-				ProtoMajor:    r.ProtoMajor,
-				ProtoMinor:    r.ProtoMinor,
-				ContentLength: size,
-				Request:       r,
-				Header:        header,
-				Body:          ioutil.NopCloser(strings.NewReader(statusText)),
-			}
-			return resp, nil
+// file is a file served by FileSystemRT
+type file interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+	Stat() (os.FileInfo, error)
+}
+
+// precompressedExt are the file extensions of precompressed
+// files of the Content-Encoding
+var precompressedExt = map[string]string{
+	"br":      ".br",
+	"gzip":    ".gz",
+	"deflate": ".zz",
+}
+
+// FilePrecompressed makes FileSystemRT serve precompressed files,
+// if exists, of the first encodings accepted by the request (according
+// to its Accept-Encoding header). Supported encodings are "br" (".br"
+// files), "gzip" (".gz" files) and "deflate" (".zz" files). If no
+// encoding is given, "br" and "gzip" will be used.
+//
+// For example, with FilePrecompressed(), a request of "/app.js"
+// that accepts gzip will be served with file "app.js.gz" and
+// header "Content-Encoding: gzip", if the file exists.
+func FilePrecompressed(encodings ...string) FileSystemOption {
+	if len(encodings) == 0 {
+		encodings = []string{"br", "gzip"}
+	}
+	return func(config *fileSystemConfig) {
+		config.precompressed = encodings
+	}
+}
+
+// openPrecompressed opens the precompressed file of name, if exists,
+// of the first encoding accepted by the request.
+func (config *fileSystemConfig) openPrecompressed(r *http.Request, name string) (f file, s os.FileInfo, encoding string) {
+	for _, encoding := range config.precompressed {
+		ext, found := precompressedExt[encoding]
+		if !found || !acceptsEncoding(r, encoding) {
+			continue
 		}
+		f, err := config.openFile(name + ext)
 		if err != nil {
-			// return other errors directly
-			// TODO: improve handle of other PathError
-			return
+			continue
 		}
-
-		s, err := f.Stat()
-		if err != nil {
-			return nil, fmt.Errorf("error getting file stat: %s",
-				err)
+		if s, err = f.Stat(); err != nil || s.IsDir() {
+			f.Close()
+			continue
 		}
+		return f, s, encoding
+	}
+	return nil, nil, ""
+}
 
-		if s.IsDir() {
-			status := http.StatusForbidden
-			statusText := http.StatusText(status)
-			size := int64(len(statusText))
-			header := make(http.Header)
-			header.Add("Content-Length", fmt.Sprintf("%d", size))
-			header.Add("Content-Type", "text/plain")
-			header.Add("Date", time.Now().Format(time.RFC1123))
-			resp = &http.Response{
-				Status:        statusText,
-				StatusCode:    status,
-				Proto:         r.Proto,
-				ProtoMajor:    r.ProtoMajor,
-				ProtoMinor:    r.ProtoMinor,
-				ContentLength: size,
-				Request:       r,
-				Header:        header,
-				Body:          ioutil.NopCloser(strings.NewReader(statusText)),
-			}
-			return
+// open the file of the request path, or in the overlay if the file
+// has been written or deleted.
+func (config *fileSystemConfig) open(name string) (f file, overlaid bool, err error) {
+	if config.overlay != nil {
+		if f, found, err := config.overlay.open(name); found {
+			return f, true, err
 		}
+	}
+	f, err = config.openFile(name)
+	return f, false, err
+}
+
+// allow returns the value of Allow header, for the methods
+// supported by FileSystemRT.
+func (config *fileSystemConfig) allow() string {
+	if config.overlay != nil {
+		return "GET, HEAD, OPTIONS, PUT, DELETE"
+	}
+	return "GET, HEAD, OPTIONS"
+}
 
-		// detect content type by extension
-		contentType := mime.TypeByExtension(filepath.Ext(path))
+// statusTextResponse returns a plain text response of the status
+// with the status text as body.
+func statusTextResponse(r *http.Request, status int) *http.Response {
+	return bytesResponse(r, status, "text/plain", []byte(http.StatusText(status)))
+}
 
-		// mock header
+// emptyResponse returns a response of the status without body
+func emptyResponse(r *http.Request, status int, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Date", time.Now().Format(time.RFC1123))
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Proto:      r.Proto,
+		ProtoMajor: r.ProtoMajor,
+		ProtoMinor: r.ProtoMinor,
+		Request:    r,
+		Header:     header,
+		Body:       http.NoBody,
+	}
+}
+
+// FileSystemRT implements http.RoundTripper by returning
+// contents of files in a given folder (as defined as `root`).
+// Files outside the folder, by ".." or by symlinks, are never
+// served. Missing files are answered with 404 (Not Found), files
+// without permission with 403 (Forbidden), and other I/O errors
+// with 500 (Internal Server Error). See FileErrorPage for custom
+// error pages.
+//
+// Files are served with Last-Modified, a content-derived ETag and
+// Cache-Control (see FileCacheControl) headers. Requests with
+// matching If-None-Match or If-Modified-Since headers are answered
+// with 304 (Not Modified). Range requests are answered with
+// 206 (Partial Content), or 416 (Range Not Satisfiable).
+//
+// GET, HEAD and OPTIONS requests are supported. Other methods are
+// answered with 405 (Method Not Allowed), unless FileWritable is
+// given.
+func FileSystemRT(root string, options ...FileSystemOption) RoundTripperFunc {
+	return newFileSystemConfig(func(name string) (file, error) {
+		return openInRoot(root, name)
+	}, options).serve
+}
+
+// openInRoot opens the file of the request path in root. Paths
+// that resolve outside root, either by ".." or by symlinks, are
+// not opened and os.ErrPermission is returned.
+func openInRoot(root, name string) (file, error) {
+	name = filepath.Join(root, filepath.FromSlash(path.Clean("/"+name)))
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	realName, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(realRoot, realName)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+	}
+	f, err := os.Open(realName)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// newFileSystemConfig returns the configuration of files opened by
+// openFile with the options applied.
+func newFileSystemConfig(openFile func(name string) (file, error), options []FileSystemOption) *fileSystemConfig {
+	config := &fileSystemConfig{
+		cacheControl:   "no-cache",
+		interruptAfter: -1,
+		openFile:       openFile,
+	}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// serve the request and apply error pages to the response
+func (config *fileSystemConfig) serve(r *http.Request) (*http.Response, error) {
+	resp, err := config.serveMethod(r)
+	if err != nil {
+		return nil, err
+	}
+	if name, found := config.errorPages[resp.StatusCode]; found {
+		config.useErrorPage(resp, name)
+	}
+	if r.Method == http.MethodHead {
+		resp.Body.Close()
+		resp.Body = http.NoBody
+	}
+	return resp, nil
+}
+
+// serveMethod serves the request according to its method
+func (config *fileSystemConfig) serveMethod(r *http.Request) (resp *http.Response, err error) {
+	switch r.Method {
+	case "", http.MethodGet, http.MethodHead:
+		return config.serveFile(r)
+	case http.MethodOptions:
 		header := make(http.Header)
-		header.Add("Content-Length", fmt.Sprintf("%d", s.Size()))
-		header.Add("Content-Type", contentType)
-		header.Add("Date", s.ModTime().Format(time.RFC1123))
+		header.Set("Allow", config.allow())
+		header.Set("Content-Length", "0")
+		return emptyResponse(r, http.StatusNoContent, header), nil
+	case http.MethodPut, http.MethodDelete:
+		if config.overlay != nil {
+			return config.serveWrite(r)
+		}
+	}
+	resp = statusTextResponse(r, http.StatusMethodNotAllowed)
+	resp.Header.Set("Allow", config.allow())
+	return resp, nil
+}
 
-		// mock response
-		resp = &http.Response{
-			Status:        http.StatusText(http.StatusOK),
-			StatusCode:    http.StatusOK,
-			Proto:         r.Proto,
-			ProtoMajor:    r.ProtoMajor,
-			ProtoMinor:    r.ProtoMinor,
-			ContentLength: s.Size(),
-			Request:       r,
-			Header:        header,
-			Body:          f,
+// serveFile serves the GET or HEAD request with the file
+func (config *fileSystemConfig) serveFile(r *http.Request) (resp *http.Response, err error) {
+
+	f, overlaid, err := config.open(r.URL.Path)
+	if err != nil {
+		return statusTextResponse(r, openErrorStatus(err)), nil
+	}
+
+	s, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return statusTextResponse(r, http.StatusInternalServerError), nil
+	}
+
+	if s.IsDir() {
+		return config.serveDir(r, f)
+	}
+
+	// detect content type by extension
+	contentType := mime.TypeByExtension(path.Ext(r.URL.Path))
+
+	// mock header
+	header := make(http.Header)
+
+	// use precompressed file, if any
+	if len(config.precompressed) > 0 {
+		header.Add("Vary", "Accept-Encoding")
+		// written files have no precompressed version
+		if cf, cs, encoding := config.openPrecompressed(r, r.URL.Path); cf != nil && !overlaid {
+			f.Close()
+			f, s = cf, cs
+			header.Add("Content-Encoding", encoding)
+		} else if cf != nil {
+			cf.Close()
 		}
-		return
 	}
+
+	etag, err := contentETag(f)
+	if err != nil {
+		f.Close()
+		return statusTextResponse(r, http.StatusInternalServerError), nil
+	}
+
+	header.Add("Content-Length", fmt.Sprintf("%d", s.Size()))
+	header.Add("Content-Type", contentType)
+	header.Add("Date", s.ModTime().Format(time.RFC1123))
+	header.Add("Last-Modified", s.ModTime().UTC().Format(http.TimeFormat))
+	header.Add("ETag", etag)
+	header.Add("Accept-Ranges", "bytes")
+	if config.cacheControl != "" {
+		header.Add("Cache-Control", config.cacheControl)
+	}
+
+	// conditional requests
+	if checkConditional(r, etag, s.ModTime()) == http.StatusNotModified {
+		f.Close()
+		return notModifiedResponse(r, header), nil
+	}
+
+	// mock response
+	resp = &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         r.Proto,
+		ProtoMajor:    r.ProtoMajor,
+		ProtoMinor:    r.ProtoMinor,
+		ContentLength: s.Size(),
+		Request:       r,
+		Header:        header,
+		Body:          f,
+	}
+	if err = serveRange(resp, f, etag, s.ModTime()); err != nil {
+		f.Close()
+		return statusTextResponse(r, http.StatusInternalServerError), nil
+	}
+	if config.interruptAfter >= 0 {
+		resp.Body = &interruptedBody{body: resp.Body, remaining: config.interruptAfter}
+	}
+	return
 }